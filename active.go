@@ -0,0 +1,114 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// GoIfActive 在WaitRoutine未被取消时运行参数传递的routines,返回被跳过的个数
+//
+// 每个fn在启动前检查内部Context,已经被取消(Cancel()、父Context取消或者超时)时跳过该fn.
+// 设置了并发限制时,获取到运行槽位后会再次检查,避免在等待期间被取消后仍然启动.
+// 等待槽位期间与其他调用相同计入Pending(),跳过时撤销登记,不计入Stats.Launched.
+// 在GoIfActive调用前已经返回的Cancel()保证其后的fn都被跳过.被跳过的fn计入Stats.Skipped
+func (c *WaitRoutine) GoIfActive(fns ...func()) (skipped int) {
+	for _, fn := range fns {
+		if c.Context().Err() != nil {
+			skipped++
+			continue
+		}
+		t := c.register("", false)
+		sem := c.acquire()
+		if c.Context().Err() != nil {
+			c.release(sem)
+			c.discard(t)
+			skipped++
+			continue
+		}
+		c.countLaunch()
+		fn := fn
+		c.spawn(func() { c.goFn(t, sem, fn) })
+	}
+	atomic.AddUint64(&c.stats.Skipped, uint64(skipped))
+	return skipped
+}
+
+// GoBefore 在运行时刻早于deadline时运行fn,返回fn是否被运行
+//
+// 设置了并发限制时,在获取到运行槽位之后才检查时间,等待期间超过deadline同样会跳过.
+// 等待槽位期间计入Wait()等待和Pending(),被跳过时撤销登记,不计入Stats.Launched和Stats.Completed,
+// 计入Stats.Skipped,可以通过Skipped()获取.
+// 用于在退出期限之后不再开始新的任务
+func (c *WaitRoutine) GoBefore(deadline time.Time, fn func()) bool {
+	t := c.register("", false)
+	sem := c.acquire()
+	if !time.Now().Before(deadline) {
+		c.release(sem)
+		c.discard(t)
+		atomic.AddUint64(&c.stats.Skipped, 1)
+		return false
+	}
+	c.countLaunch()
+	c.spawn(func() { c.goFn(t, sem, fn) })
+	return true
+}
+
+// Skipped 返回通过GoIfActive()/GoBefore()等跳过而没有运行的routine个数,即Stats.Skipped
+func (c *WaitRoutine) Skipped() uint64 {
+	return atomic.LoadUint64(&c.stats.Skipped)
+}
+
+// GoIfActive 通过默认WaitRoutine在未被取消时运行参数传递的routines,返回被跳过的个数
+func GoIfActive(fns ...func()) int {
+	return Default().GoIfActive(fns...)
+}
+
+// GoBefore 通过默认WaitRoutine在运行时刻早于deadline时运行fn
+func GoBefore(deadline time.Time, fn func()) bool {
+	return defaultRoutine().GoBefore(deadline, fn)
+}
+
+// ShouldContinue 返回ctx是否仍未结束,结束时routine应尽快返回
+//
+// 用于在routine的循环中统一检查取消,效果与select ctx.Done()的default分支相同:
+//
+//	for waitroutine.ShouldContinue(ctx) {
+//		// 处理一批任务
+//	}
+//
+// ShouldContinue足够简单,可以被内联,开销与直接select相同
+func ShouldContinue(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	default:
+		return true
+	}
+}
+
+// IsDone 返回内部Context是否已经结束(Cancel(),父Context取消或者超时),不会阻塞
+//
+// 通过非阻塞的select检查Context().Done(),没有内存分配,可以在循环中频繁调用
+func (c *WaitRoutine) IsDone() bool {
+	return !ShouldContinue(c.Context())
+}
+
+// IsDone 返回默认WaitRoutine的内部Context是否已经结束
+func IsDone() bool {
+	return Default().IsDone()
+}
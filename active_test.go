@@ -0,0 +1,192 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_GoIfActive(t *testing.T) {
+	var ran int32
+	fn := func() {
+		atomic.AddInt32(&ran, 1)
+	}
+
+	wg := New(context.Background())
+	if skipped := wg.GoIfActive(fn, fn); skipped != 0 {
+		t.Fatalf("expect nothing skipped, got %d", skipped)
+	}
+	wg.Wait()
+
+	wg.Cancel()
+	if skipped := wg.GoIfActive(fn, fn, fn); skipped != 3 {
+		t.Fatalf("expect 3 skipped, got %d", skipped)
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&ran); n != 2 {
+		t.Fatalf("expect 2 routines ran, got %d", n)
+	}
+	if n := wg.Stats().Launched; n != 2 {
+		t.Fatalf("expect skipped routines not launched, got %d launched", n)
+	}
+}
+
+func TestWaitRoutine_GoBefore(t *testing.T) {
+	var ran int32
+	fn := func() { atomic.AddInt32(&ran, 1) }
+
+	wg := New(context.Background())
+	if !wg.GoBefore(time.Now().Add(time.Hour), fn) {
+		t.Fatal("expect fn run before deadline")
+	}
+	if wg.GoBefore(time.Now().Add(-time.Second), fn) {
+		t.Fatal("expect fn skipped past deadline")
+	}
+	wg.Wait()
+
+	release := make(chan struct{})
+	wg = NewWithLimit(context.Background(), 1)
+	wg.Go(func() { <-release })
+	result := make(chan bool)
+	deadline := time.Now().Add(20 * time.Millisecond)
+	go func() { result <- wg.GoBefore(deadline, fn) }()
+	waitPending(t, wg, 1)
+	if n := wg.Running(); n != 1 {
+		t.Fatalf("expect 1 running routine while GoBefore waits, got %d", n)
+	}
+	time.Sleep(time.Until(deadline) + 10*time.Millisecond)
+	close(release)
+	if <-result {
+		t.Fatal("expect deadline checked after waiting for a slot")
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&ran); n != 1 {
+		t.Fatalf("expect 1 run, got %d", n)
+	}
+	if n := wg.Skipped(); n != 1 {
+		t.Fatalf("expect 1 skipped, got %d", n)
+	}
+	if n := wg.Stats().Launched; n != 1 {
+		t.Fatalf("expect skipped fn not launched, got %d", n)
+	}
+	if n := wg.Running(); n != 0 {
+		t.Fatalf("expect no running routine after skip, got %d", n)
+	}
+}
+
+func TestWaitRoutine_GoIfActiveBlocked(t *testing.T) {
+	release := make(chan struct{})
+	wg := NewWithLimit(context.Background(), 1)
+	wg.Go(func() { <-release })
+
+	skipped := make(chan int, 1)
+	go func() { skipped <- wg.GoIfActive(func() {}) }()
+	waitPending(t, wg, 1)
+	if n := wg.Running(); n != 1 {
+		t.Fatalf("expect 1 running routine while GoIfActive waits, got %d", n)
+	}
+	if n := wg.Stats().Launched; n != 1 {
+		t.Fatalf("expect waiting routine not counted as launched yet, got %d", n)
+	}
+
+	wg.Cancel()
+	close(release)
+	if n := <-skipped; n != 1 {
+		t.Fatalf("expect GoIfActive skipped after cancel, got %d", n)
+	}
+	wg.Wait()
+	if n := wg.Running(); n != 0 {
+		t.Fatalf("expect no running routine, got %d", n)
+	}
+	if n := wg.Pending(); n != 0 {
+		t.Fatalf("expect no pending routine, got %d", n)
+	}
+	if stats := wg.Stats(); stats.Launched != stats.Completed {
+		t.Fatalf("expect skipped routine neither launched nor completed, got %+v", stats)
+	}
+}
+
+func TestShouldContinue(t *testing.T) {
+	wg := New(context.Background())
+	n := 0
+	wg.GoRoutine(func(ctx context.Context) {
+		for ShouldContinue(ctx) {
+			if n++; n == 3 {
+				wg.Cancel()
+			}
+		}
+	})
+	wg.Wait()
+	if n != 3 {
+		t.Fatalf("expect loop stopped after cancel, got %d iterations", n)
+	}
+}
+
+func TestWaitRoutine_IsDone(t *testing.T) {
+	wg := New(context.Background())
+	if wg.IsDone() {
+		t.Fatal("expect IsDone false before cancel")
+	}
+	wg.Cancel()
+	if !wg.IsDone() {
+		t.Fatal("expect IsDone true after cancel")
+	}
+}
+
+func BenchmarkIsDone(b *testing.B) {
+	wg := New(context.Background())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if wg.IsDone() {
+			b.Fatal("expect group not done")
+		}
+	}
+}
+
+func BenchmarkShouldContinue(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < b.N; i++ {
+		if !ShouldContinue(ctx) {
+			b.Fatal("expect context not done")
+		}
+	}
+}
+
+func BenchmarkSelectDone(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < b.N; i++ {
+		select {
+		case <-ctx.Done():
+			b.Fatal("expect context not done")
+		default:
+		}
+	}
+}
+
+func waitPending(t *testing.T, wg *WaitRoutine, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for wg.Pending() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect %d pending routines, got %d", n, wg.Pending())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
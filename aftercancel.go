@@ -0,0 +1,31 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+// AfterCancel 注册在内部Context结束(Cancel(),父Context取消或者超时)后运行的fn
+//
+// fn计入Wait()等待,因此注册之后Wait()在内部Context结束且fn运行完成后才返回.
+// 多次注册的fn都会在各自的go routine中运行.
+// 等待期间不占用go routine和运行槽位,Go 1.21及以上版本通过context.AfterFunc()实现
+func (c *WaitRoutine) AfterCancel(fn func()) *WaitRoutine {
+	t := c.add("")
+	c.afterFunc(t.ctx, func() { c.goFn(t, nil, fn) })
+	return c
+}
+
+// AfterCancel 通过默认WaitRoutine注册在内部Context结束后运行的fn
+func AfterCancel(fn func()) *WaitRoutine {
+	return defaultRoutine().AfterCancel(fn)
+}
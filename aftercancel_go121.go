@@ -0,0 +1,24 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+
+package waitroutine
+
+import "context"
+
+// afterFunc 在ctx结束后于新的go routine中运行f
+func (c *WaitRoutine) afterFunc(ctx context.Context, f func()) {
+	context.AfterFunc(ctx, f)
+}
@@ -0,0 +1,41 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_AfterCancel(t *testing.T) {
+	var ran int32
+	wg := New(context.Background())
+	wg.AfterCancel(func() { atomic.AddInt32(&ran, 1) })
+	wg.AfterCancel(func() { atomic.AddInt32(&ran, 1) })
+
+	if wg.WaitTimeout(50*time.Millisecond) != WaitTimedOut {
+		t.Fatal("expect Wait blocks until the group is cancelled")
+	}
+	if n := atomic.LoadInt32(&ran); n != 0 {
+		t.Fatalf("expect callbacks not run before cancel, got %d", n)
+	}
+	wg.Cancel()
+	wg.Wait()
+	if n := atomic.LoadInt32(&ran); n != 2 {
+		t.Fatalf("expect 2 callbacks after cancel, got %d", n)
+	}
+}
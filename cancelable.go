@@ -0,0 +1,134 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+)
+
+// GoCancelable 运行routine,返回只取消该routine的CancelFunc
+//
+// routine的context在开始运行时从内部Context派生,与GoRoutine()相同经过SetInterceptor()和pprof标签的包装,
+// 调用返回的CancelFunc只取消该routine,在routine开始运行之前调用同样有效.
+// Cancel()取消所有Routine时同样会取消该routine.routine仍然计入Wait()等待.
+// routine返回后context会被释放,之后调用CancelFunc没有影响
+func (c *WaitRoutine) GoCancelable(routine Routine) context.CancelFunc {
+	own, cancel := context.WithCancel(context.Background())
+	c.goRoutineDerived(func(groupCtx context.Context) (context.Context, context.CancelFunc) {
+		ctx, stop := MergeContexts(groupCtx, own)
+		return ctx, func() {
+			stop()
+			cancel()
+		}
+	}, routine)
+	return cancel
+}
+
+// goRoutineDerived 与GoRoutine()相同运行routine,routine开始运行前通过derive从登记时的内部Context派生其context
+//
+// 派生的context代替内部Context交给Interceptor和pprof标签包装,routine返回时调用derive返回的CancelFunc
+func (c *WaitRoutine) goRoutineDerived(derive func(groupCtx context.Context) (context.Context, context.CancelFunc), routine Routine) {
+	tasks, queued := c.addN(1, kindRoutine)
+	t, sem := &tasks[0], c.acquireBatch(0, queued)
+	c.spawn(func() {
+		ctx, cancel := derive(t.ctx)
+		t.ctx = ctx
+		c.goRoutine(t, sem, func(ctx context.Context) {
+			defer cancel()
+			routine(ctx)
+		})
+	})
+}
+
+// GoUntilClosed 运行fn,并在ch被关闭时以ErrShutdown为原因取消所有Routine
+//
+// 用于将WaitRoutine与没有使用context的外部退出channel关联.
+// GoUntilClosed会启动一个不计入Wait()的监听go routine,它在ch被关闭(此时调用取消),
+// 内部Context被取消,或者所有routine结束(Done()关闭)时退出,因此Wait()返回时监听已经结束或即将结束.
+// 所有routine结束之后再关闭ch不会再取消
+func (c *WaitRoutine) GoUntilClosed(ch <-chan struct{}, fn func()) *WaitRoutine {
+	c.Go(fn)
+	ctx, done := c.Context(), c.Done()
+	go func() {
+		select {
+		case <-ch:
+			c.CancelReason(ErrShutdown)
+		case <-ctx.Done():
+		case <-done:
+		}
+	}()
+	return c
+}
+
+// GoRoutineCtx 以ctx代替内部Context运行routine,routine仍然计入Wait()等待
+//
+// routine接收的context继承ctx的值和deadline,ctx结束或者WaitRoutine被取消时都会被取消,
+// 便于在同一WaitRoutine中运行使用不同超时等context的routine.
+// 该context与GoRoutine()相同经过SetInterceptor()和pprof标签的包装,因此追踪span等值同样可以获取;
+// 内部Context携带的值(比如WithValue()添加的值)不会传递给routine
+func (c *WaitRoutine) GoRoutineCtx(ctx context.Context, routine Routine) *WaitRoutine {
+	c.goRoutineDerived(func(groupCtx context.Context) (context.Context, context.CancelFunc) {
+		return MergeContexts(ctx, groupCtx)
+	}, routine)
+	return c
+}
+
+// GoRoutineWithCancel 运行fn,fn接收内部Context和取消整个WaitRoutine的CancelFunc
+//
+// 调用cancel与调用Cancel()等同,fn在发现无法继续的错误时可以借此停止所有routine,
+// 而不需要在闭包中引用WaitRoutine
+func (c *WaitRoutine) GoRoutineWithCancel(fn func(ctx context.Context, cancel context.CancelFunc)) *WaitRoutine {
+	return c.GoRoutine(func(ctx context.Context) {
+		fn(ctx, c.Cancel)
+	})
+}
+
+// GoRoutineWithCleanup 运行routine,routine返回后调用cleanup
+//
+// 无论routine正常返回,因取消返回还是panic,cleanup都会在routine结束后调用,
+// 且在Wait()返回之前完成,用于释放数据库连接等每个routine独占的资源.
+// 与OnDrain()在所有routine结束后调用一次不同,cleanup只属于该routine.
+// cleanup中的panic与routine中的panic同样处理
+func (c *WaitRoutine) GoRoutineWithCleanup(routine Routine, cleanup func()) *WaitRoutine {
+	return c.GoRoutine(func(ctx context.Context) {
+		defer cleanup()
+		routine(ctx)
+	})
+}
+
+// GoCancelable 通过默认WaitRoutine运行routine,返回只取消该routine的CancelFunc
+func GoCancelable(routine Routine) context.CancelFunc {
+	return defaultRoutine().GoCancelable(routine)
+}
+
+// GoUntilClosed 通过默认WaitRoutine运行fn,并在ch被关闭时取消所有Routine
+func GoUntilClosed(ch <-chan struct{}, fn func()) *WaitRoutine {
+	return defaultRoutine().GoUntilClosed(ch, fn)
+}
+
+// GoRoutineCtx 通过默认WaitRoutine以ctx代替内部Context运行routine
+func GoRoutineCtx(ctx context.Context, routine Routine) *WaitRoutine {
+	return defaultRoutine().GoRoutineCtx(ctx, routine)
+}
+
+// GoRoutineWithCancel 通过默认WaitRoutine运行fn,fn接收内部Context和取消整个WaitRoutine的CancelFunc
+func GoRoutineWithCancel(fn func(ctx context.Context, cancel context.CancelFunc)) *WaitRoutine {
+	return defaultRoutine().GoRoutineWithCancel(fn)
+}
+
+// GoRoutineWithCleanup 通过默认WaitRoutine运行routine,routine返回后调用cleanup
+func GoRoutineWithCleanup(routine Routine, cleanup func()) *WaitRoutine {
+	return defaultRoutine().GoRoutineWithCleanup(routine, cleanup)
+}
@@ -0,0 +1,168 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_GoCancelable(t *testing.T) {
+	wg := New(context.Background())
+	cancelFirst := wg.GoCancelable(routine)
+	wg.GoCancelable(routine)
+
+	cancelFirst()
+	wg.WaitAny()
+	if n := wg.Running(); n != 1 {
+		t.Fatalf("expect only the cancelled routine exited, %d running", n)
+	}
+	if wg.Context().Err() != nil {
+		t.Fatal("expect group not cancelled by routine cancel")
+	}
+
+	wg.Cancel()
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect group cancel stops cancelable routines")
+	}
+}
+
+func TestWaitRoutine_GoUntilClosed(t *testing.T) {
+	shutdown := make(chan struct{})
+	wg := New(context.Background())
+	wg.GoRoutine(routine)
+	wg.GoUntilClosed(shutdown, func() {})
+
+	close(shutdown)
+	if !finished(wg, time.Second) {
+		t.Fatal("expect closing the channel cancels the group")
+	}
+	if err := wg.Cause(); !errors.Is(err, ErrShutdown) {
+		t.Fatalf("expect cause %v, got %v", ErrShutdown, err)
+	}
+
+	before := runtime.NumGoroutine()
+	wg = New(context.Background())
+	wg.GoUntilClosed(make(chan struct{}), func() {})
+	wg.Wait()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("expect watcher exits after Wait, %d goroutines remain (was %d)", n, before)
+	}
+	if wg.Cancelled() {
+		t.Fatal("expect no cancel without closing the channel")
+	}
+}
+
+func TestWaitRoutine_GoRoutineCtx(t *testing.T) {
+	type key struct{}
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), key{}, "own"), 20*time.Millisecond)
+	defer cancel()
+
+	wg := New(context.Background())
+	errCh := make(chan error, 2)
+	wg.GoRoutineCtx(ctx, func(ctx context.Context) {
+		if v := ctx.Value(key{}); v != "own" {
+			t.Errorf("expect value from provided ctx, got %v", v)
+		}
+		<-ctx.Done()
+		errCh <- ctx.Err()
+	})
+	wg.Wait()
+	if err := <-errCh; err != context.DeadlineExceeded {
+		t.Fatalf("expect %v from own ctx, got %v", context.DeadlineExceeded, err)
+	}
+
+	wg = New(context.Background())
+	wg.GoRoutineCtx(context.Background(), func(ctx context.Context) {
+		<-ctx.Done()
+		errCh <- ctx.Err()
+	})
+	wg.CancelReason(ErrShutdown)
+	if !finished(wg, time.Second) {
+		t.Fatal("expect group cancel propagates to provided ctx")
+	}
+	if err := <-errCh; err != context.Canceled {
+		t.Fatalf("expect %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestWaitRoutine_GoRoutineWithCancel(t *testing.T) {
+	wg := New(context.Background())
+	wg.GoRoutine(routine)
+	wg.GoRoutineWithCancel(func(ctx context.Context, cancel context.CancelFunc) {
+		cancel()
+	})
+	if !finished(wg, time.Second) {
+		t.Fatal("expect passed cancel stops all routines")
+	}
+	if !wg.Cancelled() {
+		t.Fatal("expect passed cancel equivalent to Cancel")
+	}
+}
+
+func TestWaitRoutine_GoRoutineWithCleanup(t *testing.T) {
+	cleaned := make(chan string, 3)
+	wg := NewWithRecover(context.Background())
+	wg.GoRoutineWithCleanup(func(ctx context.Context) {}, func() { cleaned <- "normal" })
+	wg.GoRoutineWithCleanup(func(ctx context.Context) { <-ctx.Done() }, func() { cleaned <- "cancel" })
+	wg.GoRoutineWithCleanup(func(ctx context.Context) { panic("boom") }, func() { cleaned <- "panic" })
+	wg.Cancel()
+	wg.Wait()
+	close(cleaned)
+
+	seen := make(map[string]bool)
+	for s := range cleaned {
+		seen[s] = true
+	}
+	for _, s := range []string{"normal", "cancel", "panic"} {
+		if !seen[s] {
+			t.Fatalf("expect cleanup ran before Wait returned on %s exit, got %v", s, seen)
+		}
+	}
+	var pe *PanicError
+	if !errors.As(wg.Err(), &pe) {
+		t.Fatalf("expect panic still recorded, got %v", wg.Err())
+	}
+}
+
+func TestWaitRoutine_CancelableIntercepted(t *testing.T) {
+	type key struct{}
+	wg := New(context.Background()).SetInterceptor(func(ctx context.Context, name string, next func(ctx context.Context) error) {
+		next(context.WithValue(ctx, key{}, "span"))
+	})
+	got := make(chan interface{}, 3)
+	wg.GoCancelable(func(ctx context.Context) {
+		got <- ctx.Value(key{})
+		<-ctx.Done()
+	})()
+	wg.GoRoutineCtx(context.Background(), func(ctx context.Context) { got <- ctx.Value(key{}) })
+	wg.GoRoutine(func(ctx context.Context) { got <- ctx.Value(key{}) })
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect cancel before start stops the cancelable routine")
+	}
+	close(got)
+	for v := range got {
+		if v != "span" {
+			t.Fatalf("expect every routine received the intercepted context, got %v", v)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+// SetCategoryLimit 设置类别cat中同时运行的routine最大个数,n<=0时取消该类别的单独限制
+//
+// 每个类别使用独立的槽位,一个类别达到上限不会阻塞其他类别,
+// 避免比如耗时的报表任务占满槽位导致快速任务无法运行.
+// 重新设置时替换为新的槽位,只影响之后的GoCategory()调用,这一点与可以动态调整的SetLimit()不同
+func (c *WaitRoutine) SetCategoryLimit(cat string, n int) *WaitRoutine {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n <= 0 {
+		delete(c.categories, cat)
+		return c
+	}
+	if c.categories == nil {
+		c.categories = make(map[string]*semaphore)
+	}
+	c.categories[cat] = newSemaphore(n)
+	return c
+}
+
+// acquireCategory 获取类别cat的一个运行槽位,cat未设置单独限制时使用SetLimit()设置的限制
+func (c *WaitRoutine) acquireCategory(cat string) *semaphore {
+	c.waitRate()
+	c.mu.Lock()
+	sem, ok := c.categories[cat]
+	if !ok {
+		sem = c.sem
+	}
+	c.mu.Unlock()
+	c.wait(sem, 1, 0)
+	return sem
+}
+
+// GoCategory 在类别cat中运行fn
+//
+// cat通过SetCategoryLimit()设置了限制时占用该类别的槽位,不占用SetLimit()设置的槽位;
+// 否则与Go()相同使用SetLimit()设置的限制.
+// 所有类别的routine都属于同一WaitRoutine,Wait()等待全部结束
+func (c *WaitRoutine) GoCategory(cat string, fn func()) *WaitRoutine {
+	t := c.add("")
+	sem := c.acquireCategory(cat)
+	c.spawn(func() { c.goFn(t, sem, fn) })
+	return c
+}
+
+// GoCategory 通过默认WaitRoutine在类别cat中运行fn
+func GoCategory(cat string, fn func()) *WaitRoutine {
+	return defaultRoutine().GoCategory(cat, fn)
+}
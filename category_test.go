@@ -0,0 +1,51 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_GoCategory(t *testing.T) {
+	release := make(chan struct{})
+	wg := NewWithLimit(context.Background(), 1).SetCategoryLimit("report", 1)
+
+	wg.GoCategory("report", func() { <-release })
+	go wg.GoCategory("report", func() { <-release })
+	for wg.Pending() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	quick := make(chan struct{})
+	wg.GoCategory("quick", func() { close(quick) })
+	select {
+	case <-quick:
+	case <-time.After(time.Second):
+		t.Fatal("expect quick category not blocked by report category")
+	}
+
+	wg.Go(func() { <-release })
+	go wg.Go(func() {})
+	for wg.Pending() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if n := wg.Stats().Completed; n != 5 {
+		t.Fatalf("expect 5 completed routines, got %d", n)
+	}
+}
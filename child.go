@@ -0,0 +1,41 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+// GoChild 在运行中的routine里运行fn,调用者不会因为等待运行槽位而阻塞
+//
+// fn在GoChild返回之前完成登记,调用GoChild的routine尚未结束,因此运行个数保持为正,
+// 正在进行的Wait()一定会等待fn结束.与Go()不同,获取运行槽位在fn所在的go routine中进行,
+// 设置了并发限制时,占用槽位的routine衍生子routine不会因为所有槽位都被占用而阻塞自身
+func (c *WaitRoutine) GoChild(fn func()) *WaitRoutine {
+	t := c.add("")
+	c.spawn(func() {
+		c.goFn(t, c.acquire(), fn)
+	})
+	return c
+}
+
+// goChildE 与GoChild()相同,在routine所在的go routine中获取运行槽位后运行RoutineE
+func (c *WaitRoutine) goChildE(routine RoutineE) {
+	t := c.add("")
+	c.spawn(func() {
+		c.goRoutineE(t, c.acquire(), routine)
+	})
+}
+
+// GoChild 通过默认WaitRoutine在运行中的routine里运行fn
+func GoChild(fn func()) *WaitRoutine {
+	return defaultRoutine().GoChild(fn)
+}
@@ -0,0 +1,44 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_GoChild(t *testing.T) {
+	var ran int32
+	wg := NewWithLimit(context.Background(), 1)
+	var spawn func(depth int)
+	spawn = func(depth int) {
+		atomic.AddInt32(&ran, 1)
+		if depth < 3 {
+			wg.GoChild(func() { spawn(depth + 1) })
+			wg.GoChild(func() { spawn(depth + 1) })
+		}
+	}
+	wg.Go(func() { spawn(0) })
+
+	if wg.WaitTimeout(5*time.Second) != WaitFinished {
+		t.Fatal("expect children spawned under a full limit not to deadlock")
+	}
+	if n := atomic.LoadInt32(&ran); n != 15 {
+		t.Fatalf("expect Wait covers all 15 descendants, got %d", n)
+	}
+	wg.Wait()
+}
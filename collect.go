@@ -0,0 +1,49 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Collect 通过wr为producers中每个函数运行一个routine,返回按完成顺序汇集它们发送的结果的channel
+//
+// 每个producer将结果发送到in,调用方从返回的channel按发送顺序读取,适用于流式获取部分结果.
+// producers全部返回(包括发生panic)之后channel被关闭,wr中的其他routine不影响关闭时机,
+// 因此同一wr可以多次调用Collect,也可以在之后继续运行其他routine.producers为空时返回已关闭的channel.
+// producer返回的error会被wr记录.producer在wr被取消后应通过ctx.Done()停止发送.
+// producer在其所在的go routine中获取运行槽位,设置了并发限制时Collect不会阻塞调用者.
+// channel没有缓冲,调用方需要持续读取直到其关闭,否则发送结果的producer会一直阻塞
+func Collect[T any](wr *WaitRoutine, producers ...func(ctx context.Context, in chan<- T) error) <-chan T {
+	ch := make(chan T)
+	remaining := int32(len(producers))
+	if remaining == 0 {
+		close(ch)
+		return ch
+	}
+	for _, producer := range producers {
+		producer := producer
+		wr.goChildE(func(ctx context.Context) error {
+			defer func() {
+				if atomic.AddInt32(&remaining, -1) == 0 {
+					close(ch)
+				}
+			}()
+			return producer(ctx, ch)
+		})
+	}
+	return ch
+}
@@ -0,0 +1,73 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCollect(t *testing.T) {
+	wg := New(context.Background())
+	producers := make([]func(ctx context.Context, in chan<- int) error, 10)
+	for i := range producers {
+		v := i + 1
+		producers[i] = func(ctx context.Context, in chan<- int) error {
+			in <- v
+			return nil
+		}
+	}
+	out := Collect(wg, producers...)
+
+	sum := 0
+	for v := range out {
+		sum += v
+	}
+	if sum != 55 {
+		t.Fatalf("expect sum 55, got %d", sum)
+	}
+	wg.Wait()
+
+	if _, ok := <-Collect[int](wg); ok {
+		t.Fatal("expect closed channel without producers")
+	}
+}
+
+func TestCollectStreaming(t *testing.T) {
+	wg := NewWithLimit(context.Background(), 1)
+	release := make(chan struct{})
+	out := Collect(wg, func(ctx context.Context, in chan<- int) error {
+		in <- 1
+		<-release
+		in <- 2
+		return nil
+	}, func(ctx context.Context, in chan<- int) error {
+		in <- 3
+		return nil
+	})
+	sum := <-out
+	wg.GoChild(func() {})
+	close(release)
+	for v := range out {
+		sum += v
+	}
+	if sum != 6 {
+		t.Fatalf("expect results 1, 2 and 3, got sum %d", sum)
+	}
+	wg.Wait()
+	if stats := wg.Stats(); stats.Completed != 3 || stats.Launched != 3 {
+		t.Fatalf("expect 3 routines completed, got %+v", stats)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import "context"
+
+// NewDetached 新建一个WaitRoutine,内部Context继承parent的值但不随parent取消
+//
+// 用于在请求结束之后继续运行的后台任务,比如HTTP handler返回后仍需写入的审计日志.
+// parent的取消,deadline都不会传递给WaitRoutine,Cancel()等仍然可以独立取消.
+// ParentContext()返回去除了取消的parent.Go 1.21及以上版本通过context.WithoutCancel()实现
+func NewDetached(parent context.Context) *WaitRoutine {
+	if parent == nil {
+		parent = context.Background()
+	}
+	return New(withoutCancel(parent))
+}
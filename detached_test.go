@@ -0,0 +1,48 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewDetached(t *testing.T) {
+	type key struct{}
+	parent, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "request"))
+
+	wg := NewDetached(parent)
+	got := make(chan interface{}, 1)
+	wg.GoRoutine(func(ctx context.Context) {
+		time.Sleep(20 * time.Millisecond)
+		if ctx.Err() != nil {
+			got <- ctx.Err()
+			return
+		}
+		got <- ctx.Value(key{})
+	})
+	cancel()
+	wg.Wait()
+	if v := <-got; v != "request" {
+		t.Fatalf("expect routine outlives parent with its values, got %v", v)
+	}
+
+	wg.GoRoutine(routine)
+	wg.Cancel()
+	if !finished(wg, time.Second) {
+		t.Fatal("expect Cancel still works on a detached group")
+	}
+}
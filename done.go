@@ -0,0 +1,107 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// OnDone 注册所有routine结束时调用的回调
+//
+// 运行中的routine个数降为0时,最后结束的routine所在的go routine会调用fn,每次降为0只调用一次.
+// 回调在Wait()返回之前调用完成.之后再次运行routine并全部结束时,fn会再次被调用.
+// 注册时没有运行中的routine不会立即调用fn.多次注册的回调按注册顺序调用
+func (c *WaitRoutine) OnDone(fn func()) *WaitRoutine {
+	c.mu.Lock()
+	c.onDone = append(c.onDone, fn)
+	c.mu.Unlock()
+	return c
+}
+
+// drainTimeout OnDrain()注册的fn接收的context的超时时间
+const drainTimeout = 5 * time.Second
+
+// OnDrain 注册所有routine结束之后,Wait()返回之前运行的fn,用于刷新缓冲的指标或者日志等
+//
+// fn在其他routine全部结束后运行,Wait()/Done()等待fn运行完成,与OnDone()相同每次降为0时都会运行.
+// fn接收的context继承内部Context的值,但不随WaitRoutine取消,在5秒后超时,避免刷新一直阻塞.
+// 多次注册的fn与OnDone()注册的回调按注册顺序依次运行
+func (c *WaitRoutine) OnDrain(fn func(ctx context.Context)) *WaitRoutine {
+	return c.OnDone(func() {
+		ctx, cancel := context.WithTimeout(withoutCancel(c.Context()), drainTimeout)
+		defer cancel()
+		fn(ctx)
+	})
+}
+
+// closedChan 已经关闭的channel,没有运行中的routine时Done()返回该channel
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// Done 返回一个在所有routine结束后关闭的channel
+//
+// 便于在select中与其他channel一起等待,效果与Wait()相同.
+// 没有运行中的routine时返回已经关闭的channel.
+// channel在OnDone()注册的回调调用完成后关闭,关闭之后再运行新的routine,
+// 再次调用Done()返回新的channel,在下一次全部结束时关闭
+func (c *WaitRoutine) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if atomic.LoadInt32(&c.running) == 0 && c.draining == 0 {
+		return closedChan
+	}
+	if c.doneCh == nil {
+		c.doneCh = make(chan struct{})
+	}
+	return c.doneCh
+}
+
+// Empty 返回是否没有登记的routine,此时Wait()等立即返回
+//
+// 运行中,等待运行槽位的routine,以及正在运行的OnDone()/OnDrain()回调都会使Empty()返回false.
+// 从未运行过routine,或者所有routine都已结束时返回true
+func (c *WaitRoutine) Empty() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return atomic.LoadInt32(&c.running) == 0 && c.draining == 0
+}
+
+// drained 运行中的routine个数降为0时调用,调用前需要在持有c.mu时增加c.draining
+func (c *WaitRoutine) drained() {
+	c.mu.Lock()
+	fns := c.onDone
+	c.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+
+	c.mu.Lock()
+	c.draining--
+	if c.doneCh != nil && atomic.LoadInt32(&c.running) == 0 && c.draining == 0 {
+		close(c.doneCh)
+		c.doneCh = nil
+	}
+	c.mu.Unlock()
+}
+
+// Empty 返回默认WaitRoutine是否没有登记的routine
+func Empty() bool {
+	return Default().Empty()
+}
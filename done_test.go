@@ -0,0 +1,102 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_OnDone(t *testing.T) {
+	var called int32
+	wg := New(context.Background())
+	wg.OnDone(func() {
+		atomic.AddInt32(&called, 1)
+	})
+
+	for i := 0; i < 100; i++ {
+		wg.Go(func() {})
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&called); n < 1 {
+		t.Fatalf("expect OnDone called before Wait returns, got %d", n)
+	}
+
+	atomic.StoreInt32(&called, 0)
+	release := make(chan struct{})
+	wg.Go(func() { <-release }, func() { <-release })
+	close(release)
+	wg.Wait()
+	if n := atomic.LoadInt32(&called); n != 1 {
+		t.Fatalf("expect OnDone called once per drain, got %d", n)
+	}
+}
+
+func TestWaitRoutine_Done(t *testing.T) {
+	wg := New(context.Background())
+	select {
+	case <-wg.Done():
+	default:
+		t.Fatal("expect Done closed without routines")
+	}
+
+	for round := 0; round < 2; round++ {
+		release := make(chan struct{})
+		wg.Go(func() { <-release })
+		done := wg.Done()
+		select {
+		case <-done:
+			t.Fatal("expect Done not closed while routine running")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(release)
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expect Done closed after routines finished")
+		}
+	}
+}
+
+func TestWaitRoutine_OnDrain(t *testing.T) {
+	var workers, flushed int32
+	wg := New(context.Background())
+	wg.OnDrain(func(ctx context.Context) {
+		if ctx.Err() != nil {
+			t.Errorf("expect live flush context after cancel, got %v", ctx.Err())
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expect flush context has a deadline")
+		}
+		if n := atomic.LoadInt32(&workers); n != 3 {
+			t.Errorf("expect flush after all workers, got %d finished", n)
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&flushed, 1)
+	})
+	for i := 0; i < 3; i++ {
+		wg.GoRoutine(func(ctx context.Context) {
+			<-ctx.Done()
+			atomic.AddInt32(&workers, 1)
+		})
+	}
+	wg.Cancel()
+	wg.Wait()
+	if n := atomic.LoadInt32(&flushed); n != 1 {
+		t.Fatalf("expect Wait covers the flush, got %d", n)
+	}
+}
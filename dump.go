@@ -0,0 +1,59 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Dump 返回便于阅读的WaitRoutine当前状态
+//
+// 包括名称,运行中和等待槽位的routine个数,是否被取消,每个登记的routine名称及登记以来的时间
+// (没有启用任何功能时通过Go()运行的routine不登记,只输出个数),
+// 以及到目前为止记录的error;启用SetCaptureLaunchStack()时同时输出每个routine的启动位置.
+// 可以与其他方法并发调用,比如在SIGUSR1信号处理中输出,用于排查Wait()一直无法返回等问题
+func (c *WaitRoutine) Dump() string {
+	var b strings.Builder
+	name := c.Name()
+	if name == "" {
+		name = "-"
+	}
+	fmt.Fprintf(&b, "waitroutine %s: running=%d pending=%d cancelled=%t\n",
+		name, c.Running(), c.Pending(), c.Cancelled())
+	now := time.Now()
+	for _, t := range c.runningTasks() {
+		fmt.Fprintf(&b, "  %s %s\n", t.describe(), now.Sub(t.added).Round(time.Millisecond))
+	}
+	c.mu.Lock()
+	untracked := c.untracked
+	c.mu.Unlock()
+	if untracked > 0 {
+		fmt.Fprintf(&b, "  untracked=%d\n", untracked)
+	}
+	if errs := c.Errors(); len(errs) > 0 {
+		fmt.Fprintf(&b, "errors=%d\n", len(errs))
+		for _, err := range errs {
+			fmt.Fprintf(&b, "  %v\n", err)
+		}
+	}
+	return b.String()
+}
+
+// Dump 返回便于阅读的默认WaitRoutine当前状态
+func Dump() string {
+	return Default().Dump()
+}
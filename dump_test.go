@@ -0,0 +1,64 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWaitRoutine_Dump(t *testing.T) {
+	release := make(chan struct{})
+	wg := NewWithName(context.Background(), "server")
+	wg.GoNamed("listener", func() { <-release })
+	wg.GoE(func() error { return errors.New("bad request") })
+	for wg.Stats().Failed != 1 {
+		wg.WaitAny()
+	}
+
+	dump := wg.Dump()
+	for _, want := range []string{
+		"waitroutine server: running=1 pending=0 cancelled=false",
+		"  listener ",
+		"errors=1",
+		"  bad request",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Fatalf("expect dump contains %q, got:\n%s", want, dump)
+		}
+	}
+
+	close(release)
+	wg.Wait()
+	if dump := wg.Dump(); strings.Contains(dump, "listener") {
+		t.Fatalf("expect finished routine not listed, got:\n%s", dump)
+	}
+}
+
+func TestWaitRoutine_DumpUntracked(t *testing.T) {
+	release := make(chan struct{})
+	wg := New(context.Background())
+	wg.Go(func() { <-release }, func() { <-release })
+	if dump := wg.Dump(); !strings.Contains(dump, "  untracked=2\n") {
+		t.Fatalf("expect dump counts untracked routines, got:\n%s", dump)
+	}
+	close(release)
+	wg.Wait()
+	if dump := wg.Dump(); strings.Contains(dump, "untracked") {
+		t.Fatalf("expect no untracked routine after Wait, got:\n%s", dump)
+	}
+}
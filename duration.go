@@ -0,0 +1,72 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"time"
+)
+
+// DurationMode routine运行时间的记录方式
+type DurationMode int
+
+const (
+	// DurationNone 不记录运行时间
+	DurationNone DurationMode = iota
+	// DurationLast 同名routine只保留最后一次结束的运行时间
+	DurationLast
+	// DurationTotal 同名routine累加运行时间
+	DurationTotal
+)
+
+// RecordDurations 设置已结束routine运行时间的记录方式,通过Durations()获取
+//
+// 运行时间以routine名称为key,只记录通过GoNamed()等命名的routine,
+// 未命名routine不记录,避免记录随启动次数无限增长.默认不记录.
+// 只对之后开始运行的routine生效
+func (c *WaitRoutine) RecordDurations(mode DurationMode) *WaitRoutine {
+	c.mu.Lock()
+	c.durationMode = mode
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// recordDuration 记录routine t的运行时间,未命名的routine不记录,需要持有c.mu
+func (c *WaitRoutine) recordDuration(t *task, dur time.Duration) {
+	if t.durationMode == DurationNone || t.name == "" {
+		return
+	}
+	if c.durations == nil {
+		c.durations = make(map[string]time.Duration)
+	}
+	if t.durationMode == DurationTotal {
+		c.durations[t.name] += dur
+	} else {
+		c.durations[t.name] = dur
+	}
+}
+
+// Durations 返回已结束routine的运行时间,key为routine名称
+//
+// 返回值为副本,可以在routine运行时并发调用
+func (c *WaitRoutine) Durations() map[string]time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	durations := make(map[string]time.Duration, len(c.durations))
+	for name, dur := range c.durations {
+		durations[name] = dur
+	}
+	return durations
+}
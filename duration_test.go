@@ -0,0 +1,56 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_Durations(t *testing.T) {
+	sleep := func(d time.Duration) func() {
+		return func() { <-time.After(d) }
+	}
+
+	wg := New(context.Background())
+	wg.GoNamed("untracked", sleep(0))
+	wg.Wait()
+	wg.RecordDurations(DurationLast)
+	wg.GoNamed("slow", sleep(100*time.Millisecond))
+	wg.Wait()
+	wg.GoNamed("slow", sleep(10*time.Millisecond))
+	wg.Go(sleep(0), sleep(0))
+	wg.Wait()
+
+	durations := wg.Durations()
+	if _, ok := durations["untracked"]; ok {
+		t.Fatal("expect routine before RecordDurations not recorded")
+	}
+	if len(durations) != 1 {
+		t.Fatalf("expect only named routine recorded, got %v", durations)
+	}
+	if d := durations["slow"]; d < 10*time.Millisecond || d >= 100*time.Millisecond {
+		t.Fatalf("expect last duration kept, got %v", d)
+	}
+
+	wg = New(context.Background()).RecordDurations(DurationTotal)
+	wg.GoNamed("slow", sleep(50*time.Millisecond))
+	wg.GoNamed("slow", sleep(50*time.Millisecond))
+	wg.Wait()
+	if d := wg.Durations()["slow"]; d < 100*time.Millisecond {
+		t.Fatalf("expect total duration accumulated, got %v", d)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// AsErrGroup 返回与WaitRoutine共用取消信号的*errgroup.Group及其context
+//
+// 便于已经使用errgroup的代码逐步迁移:返回的context从内部Context派生,
+// Cancel()等取消WaitRoutine时同样被取消;errgroup中的函数返回第一个error时,
+// 除了按errgroup的语义取消返回的context,还以该error为原因取消WaitRoutine(与CancelCause(err)相同).
+// errgroup.Wait()正常返回时只取消返回的context,不影响WaitRoutine.
+//
+// 与WaitRoutine不同,errgroup运行的go routine不计入Wait(),Stats()等,不占用SetLimit()的槽位,
+// 不会恢复panic,也不触发OnStart()等回调,调用方需要自行调用errgroup.Wait()等待并获取error.
+// 内部有一个监听返回的context的go routine,在errgroup.Wait()返回,
+// 出现第一个error或者WaitRoutine被取消时退出,因此必须调用errgroup.Wait()或者取消WaitRoutine
+func (c *WaitRoutine) AsErrGroup() (*errgroup.Group, context.Context) {
+	parent := c.Context()
+	eg, ctx := errgroup.WithContext(parent)
+	go func() {
+		<-ctx.Done()
+		if parent.Err() != nil {
+			return
+		}
+		if err := context.Cause(ctx); err != context.Canceled {
+			c.CancelCause(err)
+		}
+	}()
+	return eg, ctx
+}
+
+// AsErrGroup 通过默认WaitRoutine返回共用取消信号的*errgroup.Group及其context
+func AsErrGroup() (*errgroup.Group, context.Context) {
+	return defaultRoutine().AsErrGroup()
+}
@@ -0,0 +1,65 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_AsErrGroup(t *testing.T) {
+	errFirst := errors.New("first")
+	wg := New(context.Background())
+	wg.GoRoutine(routine)
+
+	eg, ctx := wg.AsErrGroup()
+	eg.Go(func() error { return errFirst })
+	eg.Go(func() error {
+		<-ctx.Done()
+		return nil
+	})
+	if err := eg.Wait(); err != errFirst {
+		t.Fatalf("expect %v, got %v", errFirst, err)
+	}
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect errgroup error cancels the WaitRoutine")
+	}
+	if err := wg.Cause(); err != errFirst {
+		t.Fatalf("expect cause %v, got %v", errFirst, err)
+	}
+
+	wg = New(context.Background())
+	eg, _ = wg.AsErrGroup()
+	eg.Go(func() error { return nil })
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if wg.Cancelled() || wg.Context().Err() != nil {
+		t.Fatal("expect successful errgroup not cancel the WaitRoutine")
+	}
+
+	eg, ctx = wg.AsErrGroup()
+	eg.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	wg.Cancel()
+	if err := eg.Wait(); err != context.Canceled {
+		t.Fatalf("expect errgroup cancelled with the WaitRoutine, got %v", err)
+	}
+}
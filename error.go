@@ -0,0 +1,218 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+)
+
+// RoutineE 可以通过GoRoutineE()函数运行的返回error的routine原型
+type RoutineE func(ctx context.Context) error
+
+// NewWithCancelOnError 新建一个WaitRoutine,任意routine返回非nil error时自动Cancel()
+//
+// 类似golang.org/x/sync/errgroup,第一个error出现后其他routine会接收到ctx.Done()信号,
+// context.Cause(ctx)为该error,Err()同样返回该error,Wait()在所有routine退出后返回
+func NewWithCancelOnError(ctx context.Context) *WaitRoutine {
+	return New(ctx, WithCancelOnError())
+}
+
+// failure 一次routine失败的记录,保存记录时的启动序号和error
+//
+// GoSupervised()等重启时复用同一个task,因此不能直接保存*task
+type failure struct {
+	id  uint64
+	err error
+}
+
+// setErr 记录routine t的error(t.err)
+//
+// 在cancelOnError模式下,第一个error在记录的同时以其为原因取消所有routine,
+// 同时出现多个error时只有最先记录的一个成为取消原因和Err()的返回值
+func (c *WaitRoutine) setErr(t *task) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failed = append(c.failed, failure{id: t.id, err: t.err})
+	if c.cancelOnError && c.firstErr == nil {
+		c.firstErr = t.err
+		c.cancelLocked(t.err)
+	}
+}
+
+// fail 记录routine返回的error并计入Stats.Failed
+func (c *WaitRoutine) fail(t *task, err error) {
+	if err == nil {
+		return
+	}
+	t.err = err
+	atomic.AddUint64(&c.stats.Failed, 1)
+	c.setErr(t)
+}
+
+func (c *WaitRoutine) goFnE(t *task, sem *semaphore, fn func() error) {
+	defer c.done(t)
+	defer c.release(sem)
+	c.begin(t)
+	if c.recoverable() {
+		defer c.recoverPanic(t)
+	}
+	c.run(t, func(context.Context) { c.fail(t, fn()) })
+}
+
+// GoE 运行参数传递的routines,类型为func() error
+//
+// 接收不定个数func() error,所有都会运行
+// 返回的error会被记录,可在Wait()之后通过Err()获取
+func (c *WaitRoutine) GoE(fns ...func() error) *WaitRoutine {
+	tasks, queued := c.addN(len(fns), kindOther)
+	for i, fn := range fns {
+		t, sem, fn := &tasks[i], c.acquireBatch(i, queued), fn
+		c.spawn(func() { c.goFnE(t, sem, fn) })
+	}
+	return c
+}
+
+func (c *WaitRoutine) goRoutineE(t *task, sem *semaphore, routine RoutineE) {
+	defer c.done(t)
+	defer c.release(sem)
+	c.begin(t)
+	if c.recoverable() {
+		defer c.recoverPanic(t)
+	}
+	c.run(t, func(ctx context.Context) { c.fail(t, routine(ctx)) })
+}
+
+// GoRoutineE 运行参数传递的routines,类型为RoutineE
+//
+// 接收不定个数RoutineE,所有都会运行
+// 返回的error会被记录,可在Wait()之后通过Err()获取
+func (c *WaitRoutine) GoRoutineE(routines ...RoutineE) *WaitRoutine {
+	tasks, queued := c.addN(len(routines), kindOther)
+	for i, routine := range routines {
+		t, sem, routine := &tasks[i], c.acquireBatch(i, queued), routine
+		c.spawn(func() { c.goRoutineE(t, sem, routine) })
+	}
+	return c
+}
+
+// Errors 返回routine运行返回的所有非nil error,包括恢复panic时的*PanicError
+//
+// error按routine的启动顺序排列,没有error时返回nil.
+// 应在Wait()返回之后调用,此时所有routine都已结束;运行期间获取已有的error使用CurrentErrors()
+func (c *WaitRoutine) Errors() []error {
+	c.mu.Lock()
+	failed := make([]failure, len(c.failed))
+	copy(failed, c.failed)
+	c.mu.Unlock()
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.SliceStable(failed, func(i, j int) bool { return failed[i].id < failed[j].id })
+	errs := make([]error, len(failed))
+	for i, f := range failed {
+		errs[i] = f.err
+	}
+	return errs
+}
+
+// CurrentErrors 返回目前为止已经结束的routine记录的error,不等待仍在运行的routine
+//
+// 可以在routine运行期间随时调用,用于轮询error并在出现严重error时提前Cancel().
+// 与Errors()按启动顺序排列不同,error按记录的先后顺序排列,
+// 之后调用返回的结果以之前的结果为前缀,新出现的error追加在末尾.没有error时返回nil
+func (c *WaitRoutine) CurrentErrors() []error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.failed) == 0 {
+		return nil
+	}
+	errs := make([]error, len(c.failed))
+	for i, f := range c.failed {
+		errs[i] = f.err
+	}
+	return errs
+}
+
+// Err 返回routine运行返回的error,没有error时返回nil
+//
+// 只有一个error时直接返回该error,多个error时通过errors.Join()按启动顺序合并,
+// 可以通过errors.Is()/errors.As()判断其中任意一个error.
+// cancelOnError模式下返回最先出现的error,与其触发的取消原因context.Cause(ctx)相同,
+// 全部error仍可通过Errors()获取.
+// 应在Wait()返回之后调用,此时所有routine都已结束
+func (c *WaitRoutine) Err() error {
+	c.mu.Lock()
+	first := c.firstErr
+	c.mu.Unlock()
+	if first != nil {
+		return first
+	}
+	errs := c.Errors()
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// WaitErr 等待所有Routine运行结束,返回Err()
+//
+// 恢复panic时(NewWithRecover()或者OnPanic()),panic记录的*PanicError同样会返回,
+// 可以通过errors.As()区分panic与routine返回的error,比如在主go routine中重新panic:
+//
+//	var pe *waitroutine.PanicError
+//	if err := wr.WaitErr(); errors.As(err, &pe) {
+//		panic(pe.Recovered)
+//	}
+func (c *WaitRoutine) WaitErr() error {
+	c.Wait()
+	return c.Err()
+}
+
+// GoE 通过默认WaitRoutine运行参数传递的routines,类型为func() error
+//
+// 接收不定个数func() error,所有都会运行
+func GoE(fns ...func() error) *WaitRoutine {
+	return defaultRoutine().GoE(fns...)
+}
+
+// GoRoutineE 通过默认WaitRoutine运行参数传递的routines,类型为RoutineE
+//
+// 接收不定个数RoutineE,所有都会运行
+func GoRoutineE(routines ...RoutineE) *WaitRoutine {
+	return defaultRoutine().GoRoutineE(routines...)
+}
+
+// Errors 通过默认WaitRoutine返回所有非nil error
+func Errors() []error {
+	return Default().Errors()
+}
+
+// CurrentErrors 通过默认WaitRoutine返回目前为止已经结束的routine记录的error
+func CurrentErrors() []error {
+	return Default().CurrentErrors()
+}
+
+// Err 通过默认WaitRoutine返回routine运行返回的error
+func Err() error {
+	return Default().Err()
+}
+
+// WaitErr 通过默认WaitRoutine等待所有Routine运行结束,返回Err()
+func WaitErr() error {
+	return Default().WaitErr()
+}
@@ -0,0 +1,193 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_GoE(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+
+	wg := New(context.Background())
+	wg.GoE(func() error {
+		<-time.After(100 * time.Millisecond)
+		return errFirst
+	}, func() error {
+		return errSecond
+	}, func() error {
+		return nil
+	})
+	wg.Wait()
+
+	err := wg.Err()
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Fatalf("expect errors %v and %v, got %v", errFirst, errSecond, err)
+	}
+	errs := wg.Errors()
+	if len(errs) != 2 || errs[0] != errFirst || errs[1] != errSecond {
+		t.Fatalf("expect errors in launch order, got %v", errs)
+	}
+}
+
+func TestWaitRoutine_GoRoutineE(t *testing.T) {
+	wg := New(context.Background())
+	wg.GoRoutineE(func(ctx context.Context) error {
+		return nil
+	})
+	wg.Wait()
+	if err := wg.Err(); err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+
+	errTimeout := errors.New("timeout")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	wg = New(ctx)
+	wg.GoRoutineE(func(ctx context.Context) error {
+		<-ctx.Done()
+		return errTimeout
+	})
+	wg.Wait()
+	if err := wg.Err(); err != errTimeout {
+		t.Fatalf("expect error %v, got %v", errTimeout, err)
+	}
+}
+
+func TestNewWithCancelOnError(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	wg := NewWithCancelOnError(context.Background())
+	wg.GoRoutine(routine, routine)
+	wg.GoRoutineE(func(ctx context.Context) error {
+		<-time.After(100 * time.Millisecond)
+		return errFailed
+	})
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("routines are not cancelled after error")
+	}
+
+	if err := wg.Err(); err != errFailed {
+		t.Fatalf("expect error %v, got %v", errFailed, err)
+	}
+	if wg.Context().Err() != context.Canceled {
+		t.Fatalf("expect context canceled, got %v", wg.Context().Err())
+	}
+}
+
+func TestWaitRoutine_WaitErr(t *testing.T) {
+	errFailed := errors.New("failed")
+	errBoom := errors.New("boom")
+
+	wg := NewWithRecover(context.Background())
+	wg.GoE(func() error { return errFailed })
+	wg.Go(func() { panic(errBoom) })
+	err := wg.WaitErr()
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("expect %v in %v", errFailed, err)
+	}
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expect *PanicError in %v", err)
+	}
+	if pe.Recovered != errBoom || len(pe.Stack) == 0 {
+		t.Fatalf("expect recovered %v with stack, got %v", errBoom, pe.Recovered)
+	}
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expect panic value reachable via errors.Is, got %v", err)
+	}
+
+	if err := New(context.Background()).Go(func() {}).WaitErr(); err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+}
+
+func TestNewWithCancelOnError_Cause(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		start := make(chan struct{})
+		wg := NewWithCancelOnError(context.Background())
+		causes := make(chan error, 1)
+		wg.GoRoutine(func(ctx context.Context) {
+			<-ctx.Done()
+			causes <- context.Cause(ctx)
+		})
+		for j := 0; j < 4; j++ {
+			err := fmt.Errorf("failure %d", j)
+			wg.GoE(func() error {
+				<-start
+				return err
+			})
+		}
+		close(start)
+		wg.Wait()
+
+		err := wg.Err()
+		if err == nil || len(wg.Errors()) != 4 {
+			t.Fatalf("expect 4 errors recorded, got %v", wg.Errors())
+		}
+		if cause := wg.Cause(); cause != err {
+			t.Fatalf("expect Cause %v equals Err %v", cause, err)
+		}
+		if cause := <-causes; cause != err {
+			t.Fatalf("expect routine observed cause %v, got %v", err, cause)
+		}
+	}
+}
+
+func TestWaitRoutine_CurrentErrors(t *testing.T) {
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	release := make(chan struct{})
+
+	wg := New(context.Background())
+	if errs := wg.CurrentErrors(); errs != nil {
+		t.Fatalf("expect no errors, got %v", errs)
+	}
+	wg.GoE(func() error {
+		<-release
+		return errSecond
+	})
+	wg.GoE(func() error { return errFirst })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(wg.CurrentErrors()) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expect error from finished routine visible before Wait")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if errs := wg.CurrentErrors(); errs[0] != errFirst {
+		t.Fatalf("expect %v, got %v", errFirst, errs)
+	}
+	close(release)
+	wg.Wait()
+
+	errs := wg.CurrentErrors()
+	if len(errs) != 2 || errs[0] != errFirst || errs[1] != errSecond {
+		t.Fatalf("expect errors in recorded order, got %v", errs)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import "sync/atomic"
+
+// flags中的标志位,表示是否启用了需要在运行routine时额外处理的功能
+//
+// 没有启用任何功能时,Go()等只需要登记和结束routine,不需要获取c.mu读取各项设置.
+// Go()此时不创建task也不登记到routine列表,只在启动和结束时各获取一次c.mu维护计数,
+// 每个fn只有启动go routine的一次内存分配
+const (
+	// flagAcquire 设置了并发限制或者启动速率,启动前需要获取运行槽位
+	flagAcquire uint32 = 1 << iota
+	// flagRecover 需要恢复panic
+	flagRecover
+	// flagHooks 设置了Logger,运行时间记录,OnStart()/OnRoutineDone()等回调或者SetInterceptor()
+	flagHooks
+	// flagSpawn 设置了SetSpawnHook(),SetSpawn()或者SetSerial()
+	flagSpawn
+	// flagStack 通过SetCaptureLaunchStack()启用了启动调用栈记录
+	flagStack
+	// flagPause 通过Pause()暂停了routine的启动
+	flagPause
+	// flagTrack 设置了名称或者SetLeakWarning(),每个routine都需要登记以便输出
+	flagTrack
+)
+
+// bare 是否没有启用任何功能,Go()可以使用不登记routine的快速路径
+func (c *WaitRoutine) bare() bool {
+	return atomic.LoadUint32(&c.flags) == 0
+}
+
+// updateFlags 根据当前设置重新计算flags,需要持有c.mu或者在WaitRoutine创建期间调用
+func (c *WaitRoutine) updateFlags() {
+	var flags uint32
+	if c.sem != nil || c.limiter != nil {
+		flags |= flagAcquire
+	}
+	if c.recover || c.panicHandler != nil {
+		flags |= flagRecover
+	}
+	if c.logger != nil || c.durationMode != DurationNone || c.onStart != nil || c.onRoutineDone != nil ||
+		c.interceptor != nil {
+		flags |= flagHooks
+	}
+	if c.spawnHook != nil || c.spawnFunc != nil || c.serial != nil {
+		flags |= flagSpawn
+	}
+	if c.captureStack {
+		flags |= flagStack
+	}
+	if c.paused != nil {
+		flags |= flagPause
+	}
+	if c.name != "" || c.leakWarning != nil {
+		flags |= flagTrack
+	}
+	atomic.StoreUint32(&c.flags, flags)
+}
+
+// hasFlag 返回是否启用了flag对应的功能
+func (c *WaitRoutine) hasFlag(flag uint32) bool {
+	return atomic.LoadUint32(&c.flags)&flag != 0
+}
@@ -0,0 +1,63 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrPanicked 通过GoValue()运行的routine发生panic时,Future.Get()返回的error
+var ErrPanicked = errors.New("waitroutine: routine panicked")
+
+// Future 通过GoValue()运行的routine的结果
+type Future[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// GoValue 通过wr运行fn,返回可以获取其结果的Future
+//
+// fn接收wr的内部Context,wr被取消时fn应尽快返回.
+// fn返回的error同样会被wr记录,在cancelOnError模式下会取消wr.
+// fn发生panic并被wr恢复时,Future.Get()返回ErrPanicked
+func GoValue[T any](wr *WaitRoutine, fn func(ctx context.Context) (T, error)) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+	wr.GoRoutineE(func(ctx context.Context) error {
+		returned := false
+		defer func() {
+			if !returned {
+				f.err = ErrPanicked
+			}
+			close(f.done)
+		}()
+		f.val, f.err = fn(ctx)
+		returned = true
+		return f.err
+	})
+	return f
+}
+
+// Get 阻塞直到routine运行结束,返回其结果
+func (f *Future[T]) Get() (T, error) {
+	<-f.done
+	return f.val, f.err
+}
+
+// Done 返回一个在routine运行结束后关闭的channel
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
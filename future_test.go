@@ -0,0 +1,50 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGoValue(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	wg := NewWithRecover(context.Background())
+	ok := GoValue(wg, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	failed := GoValue(wg, func(ctx context.Context) (string, error) {
+		return "", errFailed
+	})
+	panicked := GoValue(wg, func(ctx context.Context) (int, error) {
+		panic("future")
+	})
+
+	if v, err := ok.Get(); v != 42 || err != nil {
+		t.Fatalf("expect (42, nil), got (%v, %v)", v, err)
+	}
+	if _, err := failed.Get(); err != errFailed {
+		t.Fatalf("expect %v, got %v", errFailed, err)
+	}
+	if _, err := panicked.Get(); err != ErrPanicked {
+		t.Fatalf("expect %v, got %v", ErrPanicked, err)
+	}
+	wg.Wait()
+	if wg.Stats().Failed != 1 {
+		t.Fatalf("expect 1 failed routine, got %d", wg.Stats().Failed)
+	}
+}
@@ -1,3 +1,8 @@
 module github.com/sqos/waitroutine
 
-go 1.15
+go 1.20
+
+require (
+	golang.org/x/sync v0.7.0
+	golang.org/x/time v0.5.0
+)
@@ -0,0 +1,88 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"sort"
+	"time"
+)
+
+// Heartbeat 记录name的一次心跳,routine应在运行期间定期调用
+//
+// 用于发现仍在运行但已经卡住(没有panic也没有结束)的routine,见StuckRoutines().
+// name通常为GoNamed()等设置的routine名称,最后一个同名的routine结束时其心跳记录被删除;
+// 其他名称的记录一直保留到Reset().可以在多个go routine中同时调用
+func (c *WaitRoutine) Heartbeat(name string) {
+	c.heartbeats.Store(name, time.Now())
+}
+
+// StuckRoutines 返回最近一次心跳早于threshold之前的名称,按名称排序,没有时返回nil
+//
+// 只包含调用过Heartbeat()的名称,从未发送心跳的routine不会被视为卡住.
+// 启用SetCaptureLaunchStack()且有同名的运行中routine时,名称后附加其启动位置,
+// 比如"worker (started at main.go:42)".
+// 可以在健康检查接口中调用,比如返回非空结果时报告不健康
+func (c *WaitRoutine) StuckRoutines(threshold time.Duration) []string {
+	var stuck []string
+	c.heartbeats.Range(func(key, value interface{}) bool {
+		if time.Since(value.(time.Time)) > threshold {
+			stuck = append(stuck, key.(string))
+		}
+		return true
+	})
+	sort.Strings(stuck)
+	if len(stuck) > 0 && c.hasFlag(flagStack) {
+		running := make(map[string]*task)
+		for _, t := range c.runningTasks() {
+			if _, ok := running[t.Name()]; !ok {
+				running[t.Name()] = t
+			}
+		}
+		for i, name := range stuck {
+			if t, ok := running[name]; ok {
+				stuck[i] = t.describe()
+			}
+		}
+	}
+	return stuck
+}
+
+// unname 减少名称name的运行中routine个数,返回是否已经没有同名的routine,需要持有c.mu
+func (c *WaitRoutine) unname(name string) bool {
+	if c.named[name] > 1 {
+		c.named[name]--
+		return false
+	}
+	delete(c.named, name)
+	return true
+}
+
+// clearHeartbeats 删除所有心跳记录
+func (c *WaitRoutine) clearHeartbeats() {
+	c.heartbeats.Range(func(key, _ interface{}) bool {
+		c.heartbeats.Delete(key)
+		return true
+	})
+}
+
+// Heartbeat 通过默认WaitRoutine记录name的一次心跳
+func Heartbeat(name string) {
+	Default().Heartbeat(name)
+}
+
+// StuckRoutines 通过默认WaitRoutine返回最近一次心跳早于threshold之前的名称
+func StuckRoutines(threshold time.Duration) []string {
+	return Default().StuckRoutines(threshold)
+}
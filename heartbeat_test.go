@@ -0,0 +1,87 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_StuckRoutines(t *testing.T) {
+	wg := New(context.Background())
+	wedged, release := make(chan struct{}), make(chan struct{})
+	wg.GoNamed("healthy", func() {
+		tick := time.NewTicker(5 * time.Millisecond)
+		defer tick.Stop()
+		for {
+			wg.Heartbeat("healthy")
+			select {
+			case <-tick.C:
+			case <-release:
+				return
+			}
+		}
+	})
+	wg.GoNamed("wedged", func() {
+		wg.Heartbeat("wedged")
+		<-wedged
+	})
+
+	time.Sleep(100 * time.Millisecond)
+	stuck := wg.StuckRoutines(50 * time.Millisecond)
+	if len(stuck) != 1 || stuck[0] != "wedged" {
+		t.Fatalf("expect only wedged routine stuck, got %v", stuck)
+	}
+	close(wedged)
+	close(release)
+	wg.Wait()
+	if stuck := wg.StuckRoutines(0); stuck != nil {
+		t.Fatalf("expect heartbeats of finished routines removed, got %v", stuck)
+	}
+
+	wg.Heartbeat("external")
+	if err := wg.Reset(); err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+	if stuck := wg.StuckRoutines(0); stuck != nil {
+		t.Fatalf("expect heartbeats cleared by Reset, got %v", stuck)
+	}
+}
+
+func TestWaitRoutine_HeartbeatSharedName(t *testing.T) {
+	wg := New(context.Background())
+	quick, wedged := make(chan struct{}), make(chan struct{})
+	wg.GoNamed("worker", func() { <-quick })
+	wg.GoNamed("worker", func() {
+		wg.Heartbeat("worker")
+		<-wedged
+	})
+	for wg.StuckRoutines(0) == nil {
+		time.Sleep(time.Millisecond)
+	}
+	close(quick)
+	for wg.Running() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if stuck := wg.StuckRoutines(0); len(stuck) != 1 || stuck[0] != "worker" {
+		t.Fatalf("expect heartbeat kept while a same-named routine runs, got %v", stuck)
+	}
+	close(wedged)
+	wg.Wait()
+	if stuck := wg.StuckRoutines(0); stuck != nil {
+		t.Fatalf("expect heartbeat removed after the last same-named routine, got %v", stuck)
+	}
+}
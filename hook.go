@@ -0,0 +1,88 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"time"
+)
+
+// OnStart 注册routine开始运行时调用的回调,fn为nil时取消
+//
+// fn在routine所在的go routine中,routine函数运行之前同步调用,参数为routine名称.
+// 通过NewWithName()设置了名称时pprof标签已经设置完成,
+// 与Logger.RoutineFinished()等结束回调配合可以实现追踪span或者计时等.
+// 只对之后开始运行的routine生效
+func (c *WaitRoutine) OnStart(fn func(name string)) *WaitRoutine {
+	c.mu.Lock()
+	c.onStart = fn
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// started 调用OnStart()注册的回调
+func (c *WaitRoutine) started(t *task) {
+	if t.onStart != nil {
+		t.onStart(t.Name())
+	}
+}
+
+// Interceptor 包装每个routine的运行,用于追踪等需要在routine外层执行代码的场景
+//
+// ctx为routine的context,name为routine名称.Interceptor需要调用next一次以运行routine,
+// 传递给next的ctx即为routine接收的context,因此可以添加值,比如追踪span;
+// next返回routine返回的error(通过GoE()等运行时),没有error或者routine不返回error时为nil.
+// routine发生panic时panic会穿过Interceptor向上传递,需要记录时可以通过defer recover()后再次panic
+type Interceptor func(ctx context.Context, name string, next func(ctx context.Context) error)
+
+// SetInterceptor 设置包装每个routine运行的Interceptor,i为nil时取消
+//
+// Interceptor在OnStart()注册的回调之后,于routine所在的go routine中调用.
+// 只对之后开始运行的routine生效
+func (c *WaitRoutine) SetInterceptor(i Interceptor) *WaitRoutine {
+	c.mu.Lock()
+	c.interceptor = i
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// intercept 以ctx运行fn,设置了Interceptor时通过其运行
+func (c *WaitRoutine) intercept(t *task, ctx context.Context, fn func(ctx context.Context)) {
+	if t.interceptor == nil {
+		fn(ctx)
+		return
+	}
+	t.interceptor(ctx, t.Name(), func(ctx context.Context) error {
+		fn(ctx)
+		return t.err
+	})
+}
+
+// OnRoutineDone 注册每个routine运行结束时调用的回调,fn为nil时取消
+//
+// 与OnDone()在所有routine结束时调用不同,fn在每个routine结束后,
+// 于该routine所在的go routine中同步调用,参数为routine名称,error和运行时间.
+// routine返回error时err为该error,发生panic并被恢复时为*PanicError,
+// routine因为取消而返回时同样会调用.fn在Wait()返回之前调用完成.
+// 只对之后开始运行的routine生效
+func (c *WaitRoutine) OnRoutineDone(fn func(name string, err error, dur time.Duration)) *WaitRoutine {
+	c.mu.Lock()
+	c.onRoutineDone = fn
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
@@ -0,0 +1,125 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_OnStart(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(e string) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}
+
+	wg := NewWithName(context.Background(), "hook").OnStart(func(name string) {
+		record("start " + name)
+	})
+	wg.GoNamed("job", func() { record("run") })
+	wg.Wait()
+
+	if len(events) != 2 || events[0] != "start job" || events[1] != "run" {
+		t.Fatalf("expect start hook before routine, got %v", events)
+	}
+}
+
+func TestWaitRoutine_OnRoutineDone(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	var mu sync.Mutex
+	errs := make(map[string]error)
+	wg := NewWithRecover(context.Background()).OnRoutineDone(func(name string, err error, dur time.Duration) {
+		if dur < 0 {
+			t.Errorf("expect non-negative duration for %s, got %v", name, dur)
+		}
+		mu.Lock()
+		errs[name] = err
+		mu.Unlock()
+	})
+	wg.GoNamed("ok", func() {})
+	wg.GoNamed("panic", func() { panic("boom") })
+	wg.GoRoutineE(func(ctx context.Context) error { return errFailed })
+	wg.GoRoutine(func(ctx context.Context) { <-ctx.Done() })
+	wg.CancelAndWait()
+
+	if len(errs) != 4 {
+		t.Fatalf("expect 4 callbacks, got %v", errs)
+	}
+	if err := errs["ok"]; err != nil {
+		t.Fatalf("expect nil error for ok, got %v", err)
+	}
+	var pe *PanicError
+	if !errors.As(errs["panic"], &pe) {
+		t.Fatalf("expect *PanicError for panic, got %v", errs["panic"])
+	}
+	if err := errs["routine-3"]; err != errFailed {
+		t.Fatalf("expect %v, got %v", errFailed, err)
+	}
+	if _, ok := errs["routine-4"]; !ok {
+		t.Fatal("expect callback for cancelled routine")
+	}
+}
+
+func TestWaitRoutine_SetInterceptor(t *testing.T) {
+	type key struct{}
+	errGot := errors.New("got")
+
+	var mu sync.Mutex
+	seen := make(map[string]error)
+	wg := NewWithRecover(context.Background()).SetInterceptor(func(ctx context.Context, name string, next func(ctx context.Context) error) {
+		defer func() {
+			if r := recover(); r != nil {
+				mu.Lock()
+				seen[name] = errors.New("panic")
+				mu.Unlock()
+				panic(r)
+			}
+		}()
+		err := next(context.WithValue(ctx, key{}, name))
+		mu.Lock()
+		seen[name] = err
+		mu.Unlock()
+	})
+
+	got := make(chan interface{}, 1)
+	wg.GoNamed("plain", func() {})
+	wg.GoRoutine(func(ctx context.Context) { got <- ctx.Value(key{}) })
+	wg.GoRoutineE(func(ctx context.Context) error { return errGot })
+	wg.Go(func() { panic("boom") })
+	wg.Wait()
+
+	if v := <-got; v != "routine-2" {
+		t.Fatalf("expect routine received the intercepted context, got %v", v)
+	}
+	if err, ok := seen["plain"]; !ok || err != nil {
+		t.Fatalf("expect plain routine intercepted without error, got %v %t", err, ok)
+	}
+	if err := seen["routine-3"]; err != errGot {
+		t.Fatalf("expect next returns routine error %v, got %v", errGot, err)
+	}
+	if err := seen["routine-4"]; err == nil {
+		t.Fatal("expect panic passed through the interceptor")
+	}
+	if n := len(wg.Panics()); n != 1 {
+		t.Fatalf("expect panic still recovered, got %d", n)
+	}
+}
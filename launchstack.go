@@ -0,0 +1,87 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxLaunchDepth 记录启动调用栈的最大深度
+const maxLaunchDepth = 32
+
+// pkgPrefix 本包函数名的前缀,查找启动位置时跳过本包内部的调用
+const pkgPrefix = "github.com/sqos/waitroutine."
+
+// SetCaptureLaunchStack 设置是否在启动routine时记录调用方的调用栈
+//
+// 启用后Dump(),StuckRoutines()和SetLeakWarning()的告警会附加routine的启动位置,
+// 比如"worker (started at main.go:42)",从而知道卡住的routine是在哪里启动的,而不只是阻塞在哪里.
+// 每次启动都需要调用runtime.Callers(),有一定开销,默认不启用.只对之后启动的routine生效
+func (c *WaitRoutine) SetCaptureLaunchStack(capture bool) *WaitRoutine {
+	c.mu.Lock()
+	c.captureStack = capture
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// captureLaunch 启用了调用栈记录时返回调用add()/addN()的调用栈,否则返回nil
+func (c *WaitRoutine) captureLaunch() []uintptr {
+	if !c.hasFlag(flagStack) {
+		return nil
+	}
+	pcs := make([]uintptr, maxLaunchDepth)
+	// 跳过runtime.Callers,captureLaunch和add/addN
+	return pcs[:runtime.Callers(3, pcs)]
+}
+
+// launchSite 返回routine的启动位置"file:line",即调用栈中第一个本包之外的调用,未记录时返回空字符串
+//
+// 测试文件中的调用同样视为外部调用
+func (t *task) launchSite() string {
+	if len(t.launch) == 0 {
+		return ""
+	}
+	frames := runtime.CallersFrames(t.launch)
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pkgPrefix) || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// describe 返回routine的名称,记录了启动位置时附加"(started at file:line)"
+func (t *task) describe() string {
+	if site := t.launchSite(); site != "" {
+		return t.Name() + " (started at " + site + ")"
+	}
+	return t.Name()
+}
+
+// describeRunning 返回当前正在运行的routine的describe(),按启动顺序排列
+func (c *WaitRoutine) describeRunning() []string {
+	tasks := c.runningTasks()
+	names := make([]string, len(tasks))
+	for i, t := range tasks {
+		names[i] = t.describe()
+	}
+	return names
+}
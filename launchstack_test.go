@@ -0,0 +1,60 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWaitRoutine_SetCaptureLaunchStack(t *testing.T) {
+	release := make(chan struct{})
+	wg := New(context.Background()).SetCaptureLaunchStack(true)
+	_, file, line, _ := runtime.Caller(0)
+	wg.GoNamed("worker", func() {
+		wg.Heartbeat("worker")
+		<-release
+	})
+	wg.Go(func() { <-release })
+	site := file + ":" + strconv.Itoa(line+1)
+
+	if dump := wg.Dump(); !strings.Contains(dump, "worker (started at "+site+")") {
+		t.Fatalf("expect Dump shows launch site %s, got %q", site, dump)
+	}
+	for len(wg.StuckRoutines(0)) == 0 {
+		runtime.Gosched()
+	}
+	if stuck := wg.StuckRoutines(0); stuck[0] != "worker (started at "+site+")" {
+		t.Fatalf("expect StuckRoutines shows launch site %s, got %v", site, stuck)
+	}
+	names := wg.describeRunning()
+	if len(names) != 2 || !strings.Contains(names[1], "launchstack_test.go:") {
+		t.Fatalf("expect batch launch site recorded, got %v", names)
+	}
+	close(release)
+	wg.Wait()
+
+	release = make(chan struct{})
+	wg.SetCaptureLaunchStack(false)
+	wg.GoNamed("plain", func() { <-release })
+	if dump := wg.Dump(); strings.Contains(dump, "started at") {
+		t.Fatalf("expect no launch site when disabled, got %q", dump)
+	}
+	close(release)
+	wg.Wait()
+}
@@ -0,0 +1,80 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// leakWarning SetLeakWarning()设置的参数
+type leakWarning struct {
+	idle time.Duration
+	cb   func(names []string)
+}
+
+// SetLeakWarning 设置Wait()等待期间的泄漏告警
+//
+// Wait()等待期间,如果连续idle时间内没有任何routine结束,调用cb并传入仍在运行的routine名称,
+// 之后每经过idle仍然没有进展时再次调用.cb在内部watchdog go routine中调用,
+// watchdog在Wait()返回时退出.通过GoNamed()运行routine可以得到更有意义的名称,
+// 启用SetCaptureLaunchStack()时名称后附加启动位置,比如"worker (started at main.go:42)".
+// idle<=0或者cb为nil时取消告警
+func (c *WaitRoutine) SetLeakWarning(idle time.Duration, cb func(names []string)) *WaitRoutine {
+	var lw *leakWarning
+	if idle > 0 && cb != nil {
+		lw = &leakWarning{idle: idle, cb: cb}
+	}
+	c.mu.Lock()
+	c.leakWarning = lw
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// watchLeak 在设置了泄漏告警时启动watchdog,返回停止watchdog的函数
+func (c *WaitRoutine) watchLeak() (stop func()) {
+	c.mu.Lock()
+	lw := c.leakWarning
+	c.mu.Unlock()
+	if lw == nil {
+		return func() {}
+	}
+
+	quit := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(lw.idle)
+		defer ticker.Stop()
+		completed := atomic.LoadUint64(&c.stats.Completed)
+		for {
+			select {
+			case <-ticker.C:
+				n := atomic.LoadUint64(&c.stats.Completed)
+				if n == completed && c.Running() > 0 {
+					lw.cb(c.describeRunning())
+				}
+				completed = n
+			case <-quit:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-exited
+	}
+}
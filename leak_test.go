@@ -0,0 +1,50 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_SetLeakWarning(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		leaked []string
+	)
+	release := make(chan struct{})
+	wg := New(context.Background())
+	wg.SetLeakWarning(50*time.Millisecond, func(names []string) {
+		mu.Lock()
+		leaked = names
+		mu.Unlock()
+	})
+	wg.GoNamed("stuck", func() {
+		<-release
+	})
+	time.AfterFunc(200*time.Millisecond, func() {
+		close(release)
+	})
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if expect := []string{"stuck"}; !reflect.DeepEqual(leaked, expect) {
+		t.Fatalf("expect leak warning for %v, got %v", expect, leaked)
+	}
+}
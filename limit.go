@@ -0,0 +1,170 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+)
+
+// NewWithLimit 新建一个WaitRoutine,同时运行的routine最多为n个
+//
+// n<=0时不限制并发数,效果与New()相同
+func NewWithLimit(ctx context.Context, n int) *WaitRoutine {
+	return New(ctx, WithLimit(n))
+}
+
+// SetLimit 设置同时运行的routine最大个数,n<=0时取消限制
+//
+// 达到上限后,Go()/GoRoutine()等调用会阻塞,直到有运行中的routine结束释放槽位.
+//
+// SetLimit可以在routine运行期间调用以调整并发数,比如根据系统负载伸缩:
+// 增大限制时等待槽位的调用立即按优先级获取新增的槽位;
+// 减小到运行中的个数以下时,超出的routine继续运行直到结束,不会被中断,
+// 在运行个数降到新的限制以下之前不会再运行新的routine.
+// 取消限制时所有等待槽位的调用立即继续运行;从无限制改为有限制时,
+// 之前已经运行的routine不占用新限制的槽位
+func (c *WaitRoutine) SetLimit(n int) *WaitRoutine {
+	c.mu.Lock()
+	switch {
+	case n > 0 && c.sem != nil:
+		c.sem.resize(n)
+	case n > 0:
+		c.sem = newSemaphore(n)
+	case c.sem != nil:
+		// 已占用槽位的routine结束时仍然释放到原信号量,这里放开原信号量唤醒所有等待者
+		c.sem.resize(math.MaxInt)
+		c.sem = nil
+	}
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// Limit 返回SetLimit()设置的并发限制,没有限制时返回0
+func (c *WaitRoutine) Limit() int {
+	c.mu.Lock()
+	sem := c.sem
+	c.mu.Unlock()
+	if sem == nil {
+		return 0
+	}
+	return sem.limit()
+}
+
+// acquire 以默认优先级0获取一个运行槽位,未设置限制时返回nil
+func (c *WaitRoutine) acquire() *semaphore {
+	return c.acquirePriority(0)
+}
+
+// acquirePriority 以priority优先级获取一个运行槽位,未设置限制时返回nil
+//
+// 设置了启动速率时先等待速率限制
+func (c *WaitRoutine) acquirePriority(priority int) *semaphore {
+	if !c.hasFlag(flagAcquire) {
+		return nil
+	}
+	c.waitRate()
+	c.mu.Lock()
+	sem := c.sem
+	c.mu.Unlock()
+	c.wait(sem, 1, priority)
+	return sem
+}
+
+// wait 以priority优先级等待并获取sem权重为n的槽位,返回实际占用的槽位个数,sem为nil时直接返回
+//
+// 需要排队时计入Pending()
+func (c *WaitRoutine) wait(sem *semaphore, n, priority int) int {
+	if sem == nil {
+		return 0
+	}
+	queued := false
+	n = sem.acquire(n, priority, func() {
+		queued = true
+		atomic.AddInt32(&c.pending, 1)
+	})
+	if queued {
+		atomic.AddInt32(&c.pending, -1)
+	}
+	return n
+}
+
+// Pending 返回当前因并发限制阻塞在Go()等调用中,等待运行槽位的routine个数
+func (c *WaitRoutine) Pending() int {
+	return int(atomic.LoadInt32(&c.pending))
+}
+
+// tryAcquire 尝试获取一个运行槽位,不阻塞
+//
+// 未设置限制时总是成功并返回nil,设置了启动速率时同样需要立即满足速率限制
+func (c *WaitRoutine) tryAcquire() (*semaphore, bool) {
+	if !c.hasFlag(flagAcquire) {
+		return nil, true
+	}
+	c.mu.Lock()
+	sem := c.sem
+	c.mu.Unlock()
+	if sem != nil && !sem.tryAcquire() {
+		return nil, false
+	}
+	if !c.allowRate() {
+		c.release(sem)
+		return nil, false
+	}
+	return sem, true
+}
+
+// release 释放通过acquire获取的运行槽位
+func (c *WaitRoutine) release(sem *semaphore) {
+	if sem != nil {
+		sem.release(1)
+	}
+}
+
+// TryGo 在有空闲运行槽位时运行fn,并返回true
+//
+// 达到并发上限或者通过Pause()暂停启动时不会阻塞,直接返回false且fn不会被运行.
+// 未设置并发限制时总是运行fn并返回true
+func (c *WaitRoutine) TryGo(fn func()) bool {
+	if c.Paused() {
+		return false
+	}
+	sem, ok := c.tryAcquire()
+	if !ok {
+		return false
+	}
+	t := c.add("")
+	c.spawn(func() { c.goFn(t, sem, fn) })
+	return true
+}
+
+// GoPriority 以priority优先级运行fn
+//
+// 设置了并发限制且没有空闲槽位时,GoPriority阻塞直到获取槽位;
+// 释放槽位时等待中优先级高的先获取,相同优先级按等待顺序获取.
+// Go()等方法使用的优先级为0.未设置并发限制时priority没有作用,fn立即运行
+func (c *WaitRoutine) GoPriority(priority int, fn func()) *WaitRoutine {
+	t := c.add("")
+	sem := c.acquirePriority(priority)
+	c.spawn(func() { c.goFn(t, sem, fn) })
+	return c
+}
+
+// GoPriority 通过默认WaitRoutine以priority优先级运行fn
+func GoPriority(priority int, fn func()) *WaitRoutine {
+	return defaultRoutine().GoPriority(priority, fn)
+}
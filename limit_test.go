@@ -0,0 +1,229 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewWithLimit(t *testing.T) {
+	const limit = 3
+	var running, maxRunning int32
+
+	wg := NewWithLimit(context.Background(), limit)
+	for i := 0; i < 20; i++ {
+		wg.Go(func() {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+					break
+				}
+			}
+			<-time.After(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+	}
+	wg.Wait()
+
+	if maxRunning > limit {
+		t.Fatalf("expect at most %d running routines, got %d", limit, maxRunning)
+	}
+	if running != 0 {
+		t.Fatalf("expect no running routines after Wait, got %d", running)
+	}
+}
+
+func TestWaitRoutine_TryGo(t *testing.T) {
+	wg := New(context.Background())
+	if !wg.TryGo(func() {}) {
+		t.Fatal("expect TryGo accepted without limit")
+	}
+	wg.Wait()
+
+	release := make(chan struct{})
+	wg = NewWithLimit(context.Background(), 1)
+	if !wg.TryGo(func() { <-release }) {
+		t.Fatal("expect TryGo accepted with free slot")
+	}
+	if wg.TryGo(func() {}) {
+		t.Fatal("expect TryGo rejected when limit reached")
+	}
+	close(release)
+	wg.Wait()
+
+	if !wg.TryGo(func() {}) {
+		t.Fatal("expect TryGo accepted after slot released")
+	}
+	wg.Wait()
+}
+
+func TestWaitRoutine_Pending(t *testing.T) {
+	release := make(chan struct{})
+	wg := NewWithLimit(context.Background(), 1)
+	wg.Go(func() { <-release })
+	go wg.Go(func() {}, func() {})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for wg.Pending() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect 2 pending routines, got %d", wg.Pending())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := wg.Running(); n != 1 {
+		t.Fatalf("expect 1 running routine, got %d", n)
+	}
+	close(release)
+	for wg.Stats().Completed != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect all routines completed, got %+v", wg.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := wg.Pending(); n != 0 {
+		t.Fatalf("expect no pending routines, got %d", n)
+	}
+}
+
+func TestWaitRoutine_GoPriority(t *testing.T) {
+	release := make(chan struct{})
+	wg := NewWithLimit(context.Background(), 1)
+	wg.Go(func() { <-release })
+
+	order := make(chan int, 4)
+	for i, priority := range []int{0, 0, 5, 1} {
+		i, priority := i, priority
+		go wg.GoPriority(priority, func() { order <- i })
+		for wg.Pending() != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	close(release)
+	wg.Wait()
+	close(order)
+
+	var got []int
+	for i := range order {
+		got = append(got, i)
+	}
+	want := []int{2, 3, 0, 1}
+	if len(got) != len(want) {
+		t.Fatalf("expect %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expect %v, got %v", want, got)
+		}
+	}
+}
+
+func TestWaitRoutine_GoBatchRegistered(t *testing.T) {
+	first, second := make(chan struct{}), make(chan struct{})
+	wg := NewWithLimit(context.Background(), 1)
+	go wg.Go(func() { <-first }, func() { <-second })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for wg.Stats().Launched != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect whole batch registered up front, got %+v", wg.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(first)
+	if wg.WaitTimeout(50*time.Millisecond) != WaitTimedOut {
+		t.Fatal("expect Wait blocks on the rest of the batch")
+	}
+	close(second)
+	wg.Wait()
+	if n := wg.Stats().Completed; n != 2 {
+		t.Fatalf("expect 2 completed routines, got %d", n)
+	}
+}
+
+func TestWaitRoutine_SetLimitResize(t *testing.T) {
+	var running, maxRunning int32
+	release := make(chan struct{})
+	track := func() {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+	}
+	waitRunning := func(n int32) {
+		deadline := time.Now().Add(5 * time.Second)
+		for atomic.LoadInt32(&running) != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("expect %d running routines, got %d", n, atomic.LoadInt32(&running))
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	wg := NewWithLimit(context.Background(), 1)
+	go wg.Go(track, track, track, track)
+	waitRunning(1)
+	wg.SetLimit(3)
+	if n := wg.Limit(); n != 3 {
+		t.Fatalf("expect limit 3, got %d", n)
+	}
+	waitRunning(3)
+
+	wg.SetLimit(1)
+	time.Sleep(20 * time.Millisecond)
+	if n := atomic.LoadInt32(&running); n != 3 {
+		t.Fatalf("expect in-flight routines keep running after shrink, got %d", n)
+	}
+	if n := wg.Pending(); n != 1 {
+		t.Fatalf("expect 1 pending routine after shrink, got %d", n)
+	}
+	atomic.StoreInt32(&maxRunning, 0)
+	close(release)
+	wg.Wait()
+	if maxRunning > 3 {
+		t.Fatalf("expect at most 3 running routines, got %d", maxRunning)
+	}
+
+	block := make(chan struct{})
+	wg = NewWithLimit(context.Background(), 1)
+	go wg.Go(func() { <-block }, func() {})
+	deadline := time.Now().Add(5 * time.Second)
+	for wg.Pending() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect 1 pending routine, got %d", wg.Pending())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	wg.SetLimit(0)
+	for wg.Stats().Completed != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect waiter released when limit removed, got %+v", wg.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := wg.Limit(); n != 0 {
+		t.Fatalf("expect no limit, got %d", n)
+	}
+	close(block)
+	wg.Wait()
+}
@@ -0,0 +1,41 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"runtime"
+)
+
+// GoLocked 运行routine,routine运行期间其所在的go routine独占一个系统线程
+//
+// routine开始前调用runtime.LockOSThread(),返回或者panic时调用runtime.UnlockOSThread(),
+// 用于调用依赖线程局部状态的cgo库或者系统调用(比如切换命名空间)的场景,routine仍然计入Wait()等待.
+// 锁定期间该线程不会运行其他go routine,调度器需要为其他go routine使用额外的线程,
+// 因此只应用于确实需要线程亲和性的少量routine.
+// routine修改了无法恢复的线程状态时,应自行再调用一次runtime.LockOSThread(),
+// 使go routine结束时该线程随之退出而不是被复用
+func (c *WaitRoutine) GoLocked(routine Routine) *WaitRoutine {
+	return c.GoRoutine(func(ctx context.Context) {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		routine(ctx)
+	})
+}
+
+// GoLocked 通过默认WaitRoutine运行routine,routine运行期间其所在的go routine独占一个系统线程
+func GoLocked(routine Routine) *WaitRoutine {
+	return defaultRoutine().GoLocked(routine)
+}
@@ -0,0 +1,39 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWaitRoutine_GoLocked(t *testing.T) {
+	type key struct{}
+	got := make(chan interface{}, 1)
+	wg := NewWithRecover(context.Background()).WithValue(key{}, "locked")
+	wg.GoLocked(func(ctx context.Context) { got <- ctx.Value(key{}) })
+	wg.GoLocked(func(ctx context.Context) { panic("locked") })
+	wg.Wait()
+
+	if v := <-got; v != "locked" {
+		t.Fatalf("expect routine received the group context, got %v", v)
+	}
+	if n := len(wg.Panics()); n != 1 {
+		t.Fatalf("expect panic in locked routine recovered, got %d", n)
+	}
+	if n := wg.Stats().Completed; n != 2 {
+		t.Fatalf("expect locked routines tracked by Wait, got %d completed", n)
+	}
+}
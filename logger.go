@@ -0,0 +1,42 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"time"
+)
+
+// Logger 接收routine生命周期事件,可以对接zap等日志库
+//
+// 方法在routine所在的go routine中同步调用,实现需要是并发安全的
+type Logger interface {
+	// RoutineStarted routine开始运行
+	RoutineStarted(name string)
+	// RoutineFinished routine运行结束,dur为运行时间,发生panic时同样会调用
+	RoutineFinished(name string, dur time.Duration)
+	// RoutinePanicked routine发生panic并被恢复,只在恢复panic时调用
+	RoutinePanicked(name string, recovered interface{})
+}
+
+// SetLogger 设置接收routine生命周期事件的Logger,l为nil时不记录
+//
+// 未设置Logger时没有额外开销.只对之后开始运行的routine生效
+func (c *WaitRoutine) SetLogger(l Logger) *WaitRoutine {
+	c.mu.Lock()
+	c.logger = l
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
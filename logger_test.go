@@ -0,0 +1,77 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testLogger struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *testLogger) add(event string) {
+	l.mu.Lock()
+	l.events = append(l.events, event)
+	l.mu.Unlock()
+}
+
+func (l *testLogger) RoutineStarted(name string) {
+	l.add("started " + name)
+}
+
+func (l *testLogger) RoutineFinished(name string, dur time.Duration) {
+	l.add("finished " + name)
+}
+
+func (l *testLogger) RoutinePanicked(name string, recovered interface{}) {
+	l.add(fmt.Sprintf("panicked %s %v", name, recovered))
+}
+
+func TestWaitRoutine_SetLogger(t *testing.T) {
+	l := &testLogger{}
+	wg := NewWithRecover(context.Background())
+	wg.SetLogger(l)
+	wg.GoNamed("worker", func() {})
+	wg.Wait()
+	wg.GoNamed("crash", func() {
+		panic("boom")
+	})
+	wg.Wait()
+
+	expect := []string{
+		"started worker", "finished worker",
+		"started crash", "panicked crash boom", "finished crash",
+	}
+	if !reflect.DeepEqual(l.events, expect) {
+		t.Fatalf("expect events %v, got %v", expect, l.events)
+	}
+
+	l = &testLogger{}
+	wg.SetLogger(l).Go(func() {}, func() {})
+	wg.Wait()
+	sort.Strings(l.events)
+	expect = []string{"finished routine-3", "finished routine-4", "started routine-3", "started routine-4"}
+	if !reflect.DeepEqual(l.events, expect) {
+		t.Fatalf("expect events %v, got %v", expect, l.events)
+	}
+}
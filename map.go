@@ -0,0 +1,186 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// Map 通过wr为inputs中每个元素运行一个fn,按inputs的顺序返回结果
+//
+// Map阻塞直到自身运行的所有routine结束,不等待wr中的其他routine.
+// 返回值为最先出现的error,该error同样会被wr记录.
+// wr已经被取消时(比如cancelOnError模式下某个fn返回了error),尚未开始运行的元素不再调用fn,
+// 其结果为零值
+func Map[T any](wr *WaitRoutine, inputs []T, fn func(ctx context.Context, in T) (T, error)) ([]T, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	setErr := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+
+	results := make([]T, len(inputs))
+	for i := range inputs {
+		i := i
+		wg.Add(1)
+		wr.GoRoutineE(func(ctx context.Context) error {
+			returned := false
+			defer func() {
+				if !returned {
+					setErr(ErrPanicked)
+				}
+				wg.Done()
+			}()
+			if err := ctx.Err(); err != nil {
+				setErr(err)
+				returned = true
+				return nil
+			}
+			out, err := fn(ctx, inputs[i])
+			returned = true
+			if err != nil {
+				setErr(err)
+				return err
+			}
+			results[i] = out
+			return nil
+		})
+	}
+	wg.Wait()
+	return results, firstErr
+}
+
+// PoolMap 通过wr运行n个worker routine,对in中的每个元素调用fn,返回输出结果的channel,n<=0时运行1个worker
+//
+// 结果按完成顺序发送,fn返回error的元素没有输出.fn返回的error会被wr记录,
+// 同一worker的多个error通过errors.Join()合并;wr为cancelOnError模式时,
+// 第一个error会取消wr,所有worker随即退出.
+// in被关闭且元素全部处理完成,或者wr被取消后,最后退出的worker关闭输出channel.
+// worker在其所在的go routine中获取运行槽位,设置了并发限制时PoolMap不会阻塞调用者.
+// 输出channel没有缓冲,调用方需要持续读取直到其关闭
+func PoolMap[I, O any](wr *WaitRoutine, n int, in <-chan I, fn func(ctx context.Context, in I) (O, error)) <-chan O {
+	if n <= 0 {
+		n = 1
+	}
+	out := make(chan O)
+	workers := int32(n)
+	worker := func(ctx context.Context) error {
+		defer func() {
+			if atomic.AddInt32(&workers, -1) == 0 {
+				close(out)
+			}
+		}()
+		var errs []error
+		for {
+			var v I
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return errors.Join(errs...)
+			case v, ok = <-in:
+				if !ok {
+					return errors.Join(errs...)
+				}
+			}
+			res, err := fn(ctx, v)
+			if err != nil {
+				if wr.cancelOnError {
+					return err
+				}
+				errs = append(errs, err)
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return errors.Join(errs...)
+			case out <- res:
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		wr.goChildE(worker)
+	}
+	return out
+}
+
+// Ordered 通过wr为fns中每个函数运行一个routine,返回按fns顺序发送结果的channel
+//
+// 与Collect()按完成顺序汇集不同,先完成的结果会被缓存,直到其之前的结果全部发送后才发送,
+// 用于需要保持输入顺序的并行处理.fn返回error或者panic时该位置没有输出,
+// error会被wr记录,之后的结果仍然按顺序发送.
+// 所有结果发送完成,或者wr被取消后,输出channel被关闭.
+// 发送结果的go routine不计入Wait()等待,不占用运行槽位;输出channel没有缓冲,
+// 调用方需要持续读取直到其关闭,或者取消wr使其退出
+func Ordered[T any](wr *WaitRoutine, fns []func(ctx context.Context) (T, error)) <-chan T {
+	type result struct {
+		val   T
+		ok    bool
+		ready chan struct{}
+	}
+	results := make([]result, len(fns))
+	for i := range results {
+		results[i].ready = make(chan struct{})
+	}
+
+	out := make(chan T)
+	ctx := wr.Context()
+	go func() {
+		defer close(out)
+		for i := range results {
+			r := &results[i]
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.ready:
+			}
+			// 结果与取消同时就绪时优先退出,取消之后不再发送结果
+			if ctx.Err() != nil {
+				return
+			}
+			if !r.ok {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- r.val:
+			}
+		}
+	}()
+
+	for i, fn := range fns {
+		r, fn := &results[i], fn
+		wr.GoRoutineE(func(ctx context.Context) error {
+			defer close(r.ready)
+			val, err := fn(ctx)
+			if err != nil {
+				return err
+			}
+			r.val, r.ok = val, true
+			return nil
+		})
+	}
+	return out
+}
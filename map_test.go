@@ -0,0 +1,195 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	wg := NewWithLimit(context.Background(), 2)
+	inputs := []int{1, 2, 3, 4, 5}
+	results, err := Map(wg, inputs, func(ctx context.Context, in int) (int, error) {
+		<-time.After(time.Duration(len(inputs)-in) * 10 * time.Millisecond)
+		return in * in, nil
+	})
+	if err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+	for i, in := range inputs {
+		if results[i] != in*in {
+			t.Fatalf("expect results[%d] = %d, got %d", i, in*in, results[i])
+		}
+	}
+
+	errFailed := errors.New("failed")
+	wg = NewWithCancelOnError(context.Background())
+	wg.SetLimit(1)
+	called := 0
+	_, err = Map(wg, inputs, func(ctx context.Context, in int) (int, error) {
+		called++
+		if in == 2 {
+			return 0, errFailed
+		}
+		return in, nil
+	})
+	if err != errFailed {
+		t.Fatalf("expect %v, got %v", errFailed, err)
+	}
+	if called != 2 {
+		t.Fatalf("expect remaining work skipped after error, fn called %d times", called)
+	}
+}
+
+func TestPoolMap(t *testing.T) {
+	errOdd := errors.New("odd")
+	in := make(chan int)
+	go func() {
+		for i := 1; i <= 10; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+
+	wg := New(context.Background())
+	out := PoolMap(wg, 3, in, func(ctx context.Context, v int) (string, error) {
+		if v%2 == 1 {
+			return "", errOdd
+		}
+		return strconv.Itoa(v), nil
+	})
+	got := 0
+	for range out {
+		got++
+	}
+	wg.Wait()
+	if got != 5 {
+		t.Fatalf("expect 5 results, got %d", got)
+	}
+	if err := wg.Err(); !errors.Is(err, errOdd) {
+		t.Fatalf("expect %v recorded, got %v", errOdd, err)
+	}
+}
+
+func TestPoolMapLimited(t *testing.T) {
+	in := make(chan int)
+	go func() {
+		for i := 1; i <= 10; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+
+	wg := NewWithLimit(context.Background(), 2)
+	returned := make(chan (<-chan int), 1)
+	go func() {
+		returned <- PoolMap(wg, 4, in, func(ctx context.Context, v int) (int, error) {
+			return v * v, nil
+		})
+	}()
+	var out <-chan int
+	select {
+	case out = <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect PoolMap returns under a smaller limit")
+	}
+	sum := 0
+	for v := range out {
+		sum += v
+	}
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect workers exit after input closed")
+	}
+	if sum != 385 {
+		t.Fatalf("expect sum of squares 385, got %d", sum)
+	}
+}
+
+func TestPoolMapCancelOnError(t *testing.T) {
+	errFailed := errors.New("failed")
+	in := make(chan int)
+
+	wg := NewWithCancelOnError(context.Background())
+	out := PoolMap(wg, 2, in, func(ctx context.Context, v int) (int, error) {
+		return 0, errFailed
+	})
+	in <- 1
+	for range out {
+		t.Fatal("expect no result")
+	}
+	wg.Wait()
+	if !wg.Cancelled() {
+		t.Fatal("expect error cancels the group")
+	}
+	if err := wg.Err(); err != errFailed {
+		t.Fatalf("expect %v, got %v", errFailed, err)
+	}
+}
+
+func TestOrdered(t *testing.T) {
+	const n = 10
+	errSkip := errors.New("skip")
+	fns := make([]func(ctx context.Context) (int, error), n)
+	for i := range fns {
+		i := i
+		fns[i] = func(ctx context.Context) (int, error) {
+			time.Sleep(time.Duration(n-i) * 3 * time.Millisecond)
+			if i == 3 {
+				return 0, errSkip
+			}
+			return i, nil
+		}
+	}
+
+	wg := New(context.Background())
+	var got []int
+	for v := range Ordered(wg, fns) {
+		got = append(got, v)
+	}
+	wg.Wait()
+
+	want := []int{0, 1, 2, 4, 5, 6, 7, 8, 9}
+	if len(got) != len(want) {
+		t.Fatalf("expect %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expect results in input order %v, got %v", want, got)
+		}
+	}
+	if err := wg.Err(); err != errSkip {
+		t.Fatalf("expect %v recorded, got %v", errSkip, err)
+	}
+}
+
+func TestOrderedCancel(t *testing.T) {
+	wg := New(context.Background())
+	out := Ordered(wg, []func(ctx context.Context) (int, error){
+		func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+		func(ctx context.Context) (int, error) { return 1, nil },
+	})
+	wg.Cancel()
+	for v := range out {
+		t.Fatalf("expect no result after cancel, got %d", v)
+	}
+	wg.Wait()
+}
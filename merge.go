@@ -0,0 +1,34 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import "context"
+
+// MergeContexts 返回一个在a或者b结束时被取消的context
+//
+// 返回的context继承a的值和deadline,只通过b结束时以context.Cause(b)为原因取消.
+// 内部的监听go routine在a或者b结束,或者调用返回的CancelFunc后退出,
+// 与context.WithCancel()相同,不再使用时应调用CancelFunc以避免监听go routine泄漏
+func MergeContexts(a, b context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(a)
+	go func() {
+		select {
+		case <-b.Done():
+			cancel(context.Cause(b))
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(nil) }
+}
@@ -0,0 +1,66 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestMergeContexts(t *testing.T) {
+	type key struct{}
+	errStop := errors.New("stop")
+
+	a, cancelA := context.WithCancel(context.WithValue(context.Background(), key{}, "a"))
+	defer cancelA()
+	b, cancelB := context.WithCancelCause(context.Background())
+	ctx, cancel := MergeContexts(a, b)
+	defer cancel()
+	if v := ctx.Value(key{}); v != "a" {
+		t.Fatalf("expect value from a, got %v", v)
+	}
+	cancelB(errStop)
+	<-ctx.Done()
+	if err := context.Cause(ctx); err != errStop {
+		t.Fatalf("expect cause %v, got %v", errStop, err)
+	}
+
+	a, cancelA = context.WithCancel(context.Background())
+	ctx, cancel = MergeContexts(a, context.Background())
+	defer cancel()
+	cancelA()
+	<-ctx.Done()
+	if err := ctx.Err(); err != context.Canceled {
+		t.Fatalf("expect %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestMergeContextsNoLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		_, cancel := MergeContexts(context.Background(), context.Background())
+		cancel()
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("expect watchers exit after cancel, %d goroutines remain (was %d)", n, before)
+	}
+}
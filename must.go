@@ -0,0 +1,52 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+// MustWait 等待所有Routine运行结束,Err()不为nil时以该error panic
+//
+// 用于小工具的main()或者测试的准备阶段等希望后台出错时直接崩溃的场景,
+// 调用方需要处理error时应使用WaitErr()
+func (c *WaitRoutine) MustWait() {
+	if err := c.WaitErr(); err != nil {
+		panic(err)
+	}
+}
+
+// GoMust 运行参数传递的routines,routine返回非nil error时在该routine中以该error panic
+//
+// 没有恢复panic时(NewWithRecover()或者OnPanic())程序会立即崩溃;
+// 恢复panic时该panic被记录为*PanicError,其Recovered为routine返回的error,
+// 可以通过errors.Is()/errors.As()判断,之后MustWait()同样会panic
+func (c *WaitRoutine) GoMust(fns ...func() error) *WaitRoutine {
+	for _, fn := range fns {
+		fn := fn
+		c.Go(func() {
+			if err := fn(); err != nil {
+				panic(err)
+			}
+		})
+	}
+	return c
+}
+
+// MustWait 通过默认WaitRoutine等待所有Routine运行结束,Err()不为nil时以该error panic
+func MustWait() {
+	Default().MustWait()
+}
+
+// GoMust 通过默认WaitRoutine运行参数传递的routines,routine返回非nil error时panic
+func GoMust(fns ...func() error) *WaitRoutine {
+	return defaultRoutine().GoMust(fns...)
+}
@@ -0,0 +1,52 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWaitRoutine_MustWait(t *testing.T) {
+	wg := New(context.Background())
+	wg.GoE(func() error { return nil })
+	wg.MustWait()
+
+	errMust := errors.New("must")
+	wg = New(context.Background())
+	wg.GoE(func() error { return errMust })
+	defer func() {
+		if r := recover(); r != errMust {
+			t.Fatalf("expect panic with %v, got %v", errMust, r)
+		}
+	}()
+	wg.MustWait()
+}
+
+func TestWaitRoutine_GoMust(t *testing.T) {
+	errMust := errors.New("must")
+	wg := NewWithRecover(context.Background())
+	wg.GoMust(func() error { return nil }, func() error { return errMust })
+	err := wg.WaitErr()
+
+	var pe *PanicError
+	if !errors.As(err, &pe) || !errors.Is(err, errMust) {
+		t.Fatalf("expect recovered panic wrapping %v, got %v", errMust, err)
+	}
+	if n := len(wg.Errors()); n != 1 {
+		t.Fatalf("expect 1 error, got %d", n)
+	}
+}
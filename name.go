@@ -0,0 +1,98 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+)
+
+// NewWithName 新建一个名称为name的WaitRoutine
+//
+// 设置了名称的WaitRoutine运行routine时会通过pprof.Do()设置goroutine标签
+// {"group": name, "routine": routine名称},便于在goroutine profile中分组查看.
+// 未设置名称时不设置标签,没有额外开销
+func NewWithName(ctx context.Context, name string) *WaitRoutine {
+	return New(ctx, WithName(name))
+}
+
+// Name 返回WaitRoutine的名称
+func (c *WaitRoutine) Name() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.name
+}
+
+// SetName 将WaitRoutine的名称设置为name,name为空时取消名称
+//
+// 名称用于pprof标签和Dump()等输出,便于在有多个WaitRoutine的程序中区分.
+// 可以随时调用,只对之后登记的routine生效,已经登记的routine保持登记时的名称
+func (c *WaitRoutine) SetName(name string) *WaitRoutine {
+	c.mu.Lock()
+	c.name = name
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// labels 返回routine运行时设置的pprof标签
+func (c *WaitRoutine) labels(t *task) pprof.LabelSet {
+	return pprof.Labels("group", t.group, "routine", t.Name())
+}
+
+// Name 返回routine的名称,未命名的routine使用"routine-<id>"
+func (t *task) Name() string {
+	if t.name != "" {
+		return t.name
+	}
+	return "routine-" + strconv.FormatUint(t.id, 10)
+}
+
+// GoNamed 以name为名称运行fn
+//
+// 名称用于panic记录(PanicError.Name)和RunningNames()等诊断信息,
+// 未命名的routine默认名称为"routine-<n>",n为启动序号
+func (c *WaitRoutine) GoNamed(name string, fn func()) *WaitRoutine {
+	t := c.add(name)
+	sem := c.acquire()
+	c.spawn(func() { c.goFn(t, sem, fn) })
+	return c
+}
+
+// RunningNames 返回当前正在运行的routine名称,按启动顺序排列
+func (c *WaitRoutine) RunningNames() []string {
+	tasks := c.runningTasks()
+	names := make([]string, len(tasks))
+	for i, t := range tasks {
+		names[i] = t.Name()
+	}
+	return names
+}
+
+// GoNamed 通过默认WaitRoutine以name为名称运行fn
+func GoNamed(name string, fn func()) *WaitRoutine {
+	return defaultRoutine().GoNamed(name, fn)
+}
+
+// RunningNames 通过默认WaitRoutine返回当前正在运行的routine名称
+func RunningNames() []string {
+	return Default().RunningNames()
+}
+
+// SetName 设置默认WaitRoutine的名称,一般在程序启动时调用一次
+func SetName(name string) *WaitRoutine {
+	return Default().SetName(name)
+}
@@ -0,0 +1,98 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"reflect"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+func TestWaitRoutine_GoNamed(t *testing.T) {
+	release := make(chan struct{})
+	wg := NewWithRecover(context.Background())
+	wg.GoNamed("first", func() {
+		<-release
+	}).Go(func() {
+		<-release
+	}).GoNamed("crash", func() {
+		<-release
+		panic("named")
+	})
+
+	expect := []string{"first", "routine-2", "crash"}
+	if names := wg.RunningNames(); !reflect.DeepEqual(names, expect) {
+		t.Fatalf("expect running names %v, got %v", expect, names)
+	}
+	close(release)
+	wg.Wait()
+
+	if names := wg.RunningNames(); len(names) != 0 {
+		t.Fatalf("expect no running names, got %v", names)
+	}
+	panics := wg.Panics()
+	if len(panics) != 1 || panics[0].Name != "crash" {
+		t.Fatalf("expect panic of routine crash, got %v", panics)
+	}
+}
+
+func TestNewWithName(t *testing.T) {
+	wg := NewWithName(context.Background(), "workers")
+	if wg.Name() != "workers" {
+		t.Fatalf("expect name workers, got %s", wg.Name())
+	}
+
+	var group, name string
+	wg.GoRoutine(func(ctx context.Context) {
+		group, _ = pprof.Label(ctx, "group")
+		name, _ = pprof.Label(ctx, "routine")
+	})
+	wg.Wait()
+	if group != "workers" || name != "routine-1" {
+		t.Fatalf("expect labels {workers routine-1}, got {%s %s}", group, name)
+	}
+}
+
+func TestWaitRoutine_SetName(t *testing.T) {
+	wg := New(context.Background())
+	labels := make(chan string, 2)
+	wg.GoRoutine(func(ctx context.Context) {
+		group, _ := pprof.Label(ctx, "group")
+		labels <- group
+	})
+	wg.Wait()
+
+	wg.SetName("reports")
+	if wg.Name() != "reports" {
+		t.Fatalf("expect name reports, got %s", wg.Name())
+	}
+	wg.GoRoutine(func(ctx context.Context) {
+		group, _ := pprof.Label(ctx, "group")
+		labels <- group
+	})
+	wg.Wait()
+
+	if group := <-labels; group != "" {
+		t.Fatalf("expect no label before SetName, got %q", group)
+	}
+	if group := <-labels; group != "reports" {
+		t.Fatalf("expect label reports after SetName, got %q", group)
+	}
+	if dump := wg.Dump(); !strings.HasPrefix(dump, "waitroutine reports:") {
+		t.Fatalf("expect dump uses the name, got %q", dump)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"golang.org/x/time/rate"
+)
+
+// Option New()的选项,用于在创建WaitRoutine时进行设置
+//
+// 选项按传递的顺序应用,效果与创建后调用对应的方法相同
+type Option func(c *WaitRoutine)
+
+// WithLimit 设置同时运行的routine最大个数,见SetLimit()
+func WithLimit(n int) Option {
+	return func(c *WaitRoutine) {
+		c.SetLimit(n)
+	}
+}
+
+// WithName 设置WaitRoutine的名称,见NewWithName()
+func WithName(name string) Option {
+	return func(c *WaitRoutine) {
+		c.SetName(name)
+	}
+}
+
+// WithRecover 恢复routine发生的panic,见NewWithRecover()
+func WithRecover() Option {
+	return func(c *WaitRoutine) {
+		c.mu.Lock()
+		c.recover = true
+		c.updateFlags()
+		c.mu.Unlock()
+	}
+}
+
+// WithCancelOnError 任意routine返回非nil error时自动取消,见NewWithCancelOnError()
+func WithCancelOnError() Option {
+	return func(c *WaitRoutine) {
+		c.mu.Lock()
+		c.cancelOnError = true
+		c.mu.Unlock()
+	}
+}
+
+// WithRate 设置routine的启动速率,每秒最多启动r个,允许突发burst个,见SetRate()
+func WithRate(r rate.Limit, burst int) Option {
+	return func(c *WaitRoutine) {
+		c.SetRate(r, burst)
+	}
+}
+
+// WithInterceptor 设置包装每个routine运行的Interceptor,见SetInterceptor()
+func WithInterceptor(i Interceptor) Option {
+	return func(c *WaitRoutine) {
+		c.SetInterceptor(i)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	wg := New(context.Background(),
+		WithLimit(2),
+		WithName("options"),
+		WithRecover(),
+		WithCancelOnError(),
+		WithRate(rate.Inf, 1),
+	)
+	if n := wg.Limit(); n != 2 {
+		t.Fatalf("expect limit 2, got %d", n)
+	}
+	if name := wg.Name(); name != "options" {
+		t.Fatalf("expect name options, got %q", name)
+	}
+	if wg.rateLimiter() == nil {
+		t.Fatal("expect rate limiter set")
+	}
+
+	wg.Go(func() { panic("recovered") })
+	wg.Wait()
+	if n := len(wg.Panics()); n != 1 {
+		t.Fatalf("expect panic recovered, got %d panics", n)
+	}
+
+	var pe *PanicError
+	if err := wg.Cause(); !errors.As(err, &pe) {
+		t.Fatalf("expect cancel on error with the recovered panic, got %v", err)
+	}
+
+	wg = New(context.Background())
+	if wg.Limit() != 0 || wg.Name() != "" || wg.recoverable() || wg.cancelOnError {
+		t.Fatal("expect New without options has no configuration")
+	}
+}
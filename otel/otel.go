@@ -0,0 +1,62 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otel 通过OpenTelemetry追踪waitroutine运行的routine
+//
+// 独立为子模块,不使用追踪时核心包不依赖OpenTelemetry.
+// go.mod依赖已经发布的包含Interceptor的waitroutine版本,同时修改两个模块时,
+// 在仓库根目录通过go.work使用本地的核心包(go.work不提交到仓库):
+//
+//	go work init . ./otel
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sqos/waitroutine"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer 返回waitroutine.New()的选项,每个routine运行在tracer创建的span中
+//
+// 见Interceptor()
+func WithTracer(tracer trace.Tracer) waitroutine.Option {
+	return waitroutine.WithInterceptor(Interceptor(tracer))
+}
+
+// Interceptor 返回以tracer为每个routine创建span的waitroutine.Interceptor,可以通过SetInterceptor()设置
+//
+// span从routine的context派生,即以WaitRoutine父Context中的span为父span,名称为routine名称,
+// routine接收的context携带该span,routine返回时结束.
+// routine返回error时记录该error并将状态设置为codes.Error;发生panic时同样记录后继续向上传递
+func Interceptor(tracer trace.Tracer) waitroutine.Interceptor {
+	return func(ctx context.Context, name string, next func(ctx context.Context) error) {
+		ctx, span := tracer.Start(ctx, name)
+		defer span.End()
+		defer func() {
+			if r := recover(); r != nil {
+				err := fmt.Errorf("panic: %v", r)
+				span.RecordError(err, trace.WithStackTrace(true))
+				span.SetStatus(codes.Error, err.Error())
+				panic(r)
+			}
+		}()
+		if err := next(ctx); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+}
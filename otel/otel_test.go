@@ -0,0 +1,74 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sqos/waitroutine"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	parentCtx, parent := tracer.Start(context.Background(), "parent")
+
+	errFail := errors.New("fail")
+	wg := waitroutine.New(parentCtx, WithTracer(tracer), waitroutine.WithRecover())
+	spanCh := make(chan trace.SpanContext, 1)
+	wg.GoNamed("ok", func() {})
+	wg.GoRoutineE(func(ctx context.Context) error {
+		spanCh <- trace.SpanContextFromContext(ctx)
+		return errFail
+	})
+	wg.Go(func() { panic("boom") })
+	wg.Wait()
+	parent.End()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	if len(spans) != 4 {
+		t.Fatalf("expect 3 routine spans and the parent, got %d", len(spans))
+	}
+	for _, name := range []string{"ok", "routine-2", "routine-3"} {
+		s, ok := spans[name]
+		if !ok {
+			t.Fatalf("expect span %s recorded", name)
+		}
+		if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("expect span %s derived from the group context span", name)
+		}
+	}
+	if sc := <-spanCh; sc.SpanID() != spans["routine-2"].SpanContext().SpanID() {
+		t.Fatal("expect routine context carries its span")
+	}
+	if s := spans["ok"]; s.Status().Code != codes.Unset {
+		t.Fatalf("expect ok span without error status, got %v", s.Status())
+	}
+	if s := spans["routine-2"]; s.Status().Code != codes.Error || s.Status().Description != errFail.Error() {
+		t.Fatalf("expect error recorded, got %v", s.Status())
+	}
+	if s := spans["routine-3"]; s.Status().Code != codes.Error || len(s.Events()) == 0 {
+		t.Fatalf("expect panic recorded, got %v", s.Status())
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// PanicError routine发生panic并被恢复后记录的error
+type PanicError struct {
+	// Name 发生panic的routine名称
+	Name string
+	// Recovered recover()返回的值
+	Recovered interface{}
+	// Stack 发生panic的go routine的调用栈
+	Stack []byte
+}
+
+// Error 实现error接口
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("waitroutine: routine %s panic: %v", e.Name, e.Recovered)
+}
+
+// Unwrap Recovered为error时返回该error,便于通过errors.Is()判断panic(err)的原因
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Recovered.(error)
+	return err
+}
+
+// NewWithRecover 新建一个WaitRoutine,routine发生panic时会被恢复而不是导致进程退出
+//
+// 恢复的panic会转换为*PanicError记录下来,可以通过Err()或者Panics()获取.
+// 通过New()创建的WaitRoutine不会恢复panic,保持panic向上传递的行为
+func NewWithRecover(ctx context.Context) *WaitRoutine {
+	return New(ctx, WithRecover())
+}
+
+// OnPanic 注册routine发生panic时的处理函数
+//
+// 注册后routine的panic总会被恢复,handler在发生panic的go routine中被调用,
+// 调用发生在该routine被标记为结束之前.恢复的panic同样会记录为*PanicError.
+// 未注册handler时,是否恢复panic取决于是否通过NewWithRecover()创建
+func (c *WaitRoutine) OnPanic(handler func(recovered interface{}, stack []byte)) *WaitRoutine {
+	c.mu.Lock()
+	c.panicHandler = handler
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// recoverable 是否需要恢复routine的panic
+func (c *WaitRoutine) recoverable() bool {
+	return c.hasFlag(flagRecover)
+}
+
+// recoverPanic 恢复panic并记录为*PanicError,必须直接通过defer调用
+func (c *WaitRoutine) recoverPanic(t *task) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	t.err = c.panicked(t, r)
+	c.setErr(t)
+}
+
+// panicked 将routine t恢复的panic r记录为*PanicError,并调用Logger和OnPanic()注册的处理函数
+func (c *WaitRoutine) panicked(t *task, r interface{}) *PanicError {
+	pe := &PanicError{Name: t.Name(), Recovered: r, Stack: debug.Stack()}
+	atomic.AddUint64(&c.stats.Panicked, 1)
+	c.mu.Lock()
+	c.panics = append(c.panics, pe)
+	handler := c.panicHandler
+	c.mu.Unlock()
+	if t.logger != nil {
+		t.logger.RoutinePanicked(pe.Name, pe.Recovered)
+	}
+	if handler != nil {
+		handler(pe.Recovered, pe.Stack)
+	}
+	return pe
+}
+
+// Panics 返回所有被恢复的panic,没有panic时返回nil
+func (c *WaitRoutine) Panics() []*PanicError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.panics) == 0 {
+		return nil
+	}
+	panics := make([]*PanicError, len(c.panics))
+	copy(panics, c.panics)
+	return panics
+}
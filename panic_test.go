@@ -0,0 +1,75 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewWithRecover(t *testing.T) {
+	wg := NewWithRecover(context.Background())
+	wg.Go(func() {
+		panic("go panic")
+	}).GoRoutine(func(ctx context.Context) {
+		panic("routine panic")
+	}).GoE(func() error {
+		return nil
+	})
+	wg.Wait()
+
+	panics := wg.Panics()
+	if len(panics) != 2 {
+		t.Fatalf("expect 2 panics, got %d", len(panics))
+	}
+	for _, pe := range panics {
+		if pe.Recovered != "go panic" && pe.Recovered != "routine panic" {
+			t.Fatalf("unexpected recovered value %v", pe.Recovered)
+		}
+		if !bytes.Contains(pe.Stack, []byte("panic_test.go")) {
+			t.Fatalf("stack does not contain panic location:\n%s", pe.Stack)
+		}
+	}
+	var pe *PanicError
+	if !errors.As(wg.Err(), &pe) {
+		t.Fatalf("expect *PanicError, got %v", wg.Err())
+	}
+}
+
+func TestWaitRoutine_OnPanic(t *testing.T) {
+	var (
+		recovered interface{}
+		stack     []byte
+	)
+	wg := New(context.Background())
+	wg.OnPanic(func(r interface{}, s []byte) {
+		recovered, stack = r, s
+	}).Go(func() {
+		panic("handled")
+	})
+	wg.Wait()
+
+	if recovered != "handled" {
+		t.Fatalf("expect recovered value handled, got %v", recovered)
+	}
+	if !bytes.Contains(stack, []byte("panic_test.go")) {
+		t.Fatalf("stack does not contain panic location:\n%s", stack)
+	}
+	if len(wg.Panics()) != 1 {
+		t.Fatalf("expect 1 panic, got %d", len(wg.Panics()))
+	}
+}
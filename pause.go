@@ -0,0 +1,81 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+// Pause 暂停启动新的routine,已经运行的routine不受影响
+//
+// 暂停期间Go()/GoRoutine()等调用在登记routine之前阻塞,直到Resume()或者WaitRoutine被取消,
+// 用于维护期间停止接收新任务,同时让运行中的routine继续完成.
+// 阻塞中的调用尚未登记,不计入Wait()和Running()等,因此暂停期间Wait()仍然可以在运行中的routine结束后返回.
+// Cancel()优先于暂停:被取消时阻塞中的调用立即继续并启动routine,routine会接收到ctx.Done()信号.
+// TryGo()在暂停期间返回false.已经暂停时再次调用没有影响
+func (c *WaitRoutine) Pause() *WaitRoutine {
+	c.mu.Lock()
+	if c.paused == nil {
+		c.paused = make(chan struct{})
+		c.updateFlags()
+	}
+	c.mu.Unlock()
+	return c
+}
+
+// Resume 恢复通过Pause()暂停的启动,阻塞中的调用继续运行routine.没有暂停时调用没有影响
+func (c *WaitRoutine) Resume() *WaitRoutine {
+	c.mu.Lock()
+	if c.paused != nil {
+		close(c.paused)
+		c.paused = nil
+		c.updateFlags()
+	}
+	c.mu.Unlock()
+	return c
+}
+
+// Paused 返回是否通过Pause()暂停了启动
+func (c *WaitRoutine) Paused() bool {
+	if !c.hasFlag(flagPause) {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused != nil
+}
+
+// gate 暂停启动时阻塞直到Resume()或者WaitRoutine被取消
+func (c *WaitRoutine) gate() {
+	if !c.hasFlag(flagPause) {
+		return
+	}
+	c.mu.Lock()
+	paused, ctx := c.paused, c.ctx
+	c.mu.Unlock()
+	if paused == nil {
+		return
+	}
+	select {
+	case <-paused:
+	case <-ctx.Done():
+	}
+}
+
+// Pause 暂停通过默认WaitRoutine启动新的routine
+func Pause() *WaitRoutine {
+	return Default().Pause()
+}
+
+// Resume 恢复通过默认WaitRoutine启动routine
+func Resume() *WaitRoutine {
+	return Default().Resume()
+}
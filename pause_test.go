@@ -0,0 +1,76 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_Pause(t *testing.T) {
+	release := make(chan struct{})
+	wg := New(context.Background())
+	wg.Go(func() { <-release })
+	wg.Pause()
+	if !wg.Paused() {
+		t.Fatal("expect group paused")
+	}
+
+	launched := make(chan struct{})
+	ran := make(chan struct{})
+	go func() {
+		wg.Go(func() { close(ran) })
+		close(launched)
+	}()
+	if wg.TryGo(func() {}) {
+		t.Fatal("expect TryGo rejected while paused")
+	}
+	close(release)
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect Wait returns while paused once in-flight routines finish")
+	}
+	select {
+	case <-launched:
+		t.Fatal("expect launch blocked while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	wg.Resume()
+	if wg.Paused() {
+		t.Fatal("expect group resumed")
+	}
+	<-launched
+	<-ran
+	wg.Wait()
+}
+
+func TestWaitRoutine_PauseCancel(t *testing.T) {
+	wg := New(context.Background()).Pause()
+	launched := make(chan error, 1)
+	go wg.GoRoutine(func(ctx context.Context) { launched <- ctx.Err() })
+	time.Sleep(20 * time.Millisecond)
+	wg.Cancel()
+
+	select {
+	case err := <-launched:
+		if err == nil {
+			t.Fatal("expect routine launched by cancel observes cancellation")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect Cancel overrides the pause")
+	}
+	wg.Wait()
+}
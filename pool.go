@@ -0,0 +1,65 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import "context"
+
+// Pool 运行n个worker routine,从tasks中依次取出fn并运行,n<=0时运行1个worker
+//
+// worker在tasks被关闭且其中的fn全部取出,或者内部Context被取消时退出,
+// 因此关闭tasks之后Wait()在剩余的fn运行完成后返回.
+// 取消时worker不再取出新的fn,已经取出的fn仍会运行完成.
+// 与GoChild()相同,worker在其所在的go routine中获取运行槽位,设置了并发限制时Pool不会阻塞调用者.
+// 恢复panic时(NewWithRecover()或者OnPanic()),fn的panic被逐个恢复并记录,worker继续取出下一个fn
+func (c *WaitRoutine) Pool(n int, tasks <-chan func()) *WaitRoutine {
+	if n <= 0 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		t := c.add("")
+		c.spawn(func() {
+			c.goRoutine(t, c.acquire(), func(ctx context.Context) { c.poolWorker(ctx, t, tasks) })
+		})
+	}
+	return c
+}
+
+// poolWorker Pool()的worker,从tasks中依次取出fn并运行
+func (c *WaitRoutine) poolWorker(ctx context.Context, t *task, tasks <-chan func()) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case fn, ok := <-tasks:
+			if !ok {
+				return
+			}
+			c.poolTask(t, fn)
+		}
+	}
+}
+
+// poolTask 运行worker t取出的fn,需要恢复panic时只恢复fn的panic
+func (c *WaitRoutine) poolTask(t *task, fn func()) {
+	if c.recoverable() {
+		defer c.recoverPanic(t)
+	}
+	fn()
+}
+
+// Pool 通过默认WaitRoutine运行n个从tasks中取出fn并运行的worker routine
+func Pool(n int, tasks <-chan func()) *WaitRoutine {
+	return defaultRoutine().Pool(n, tasks)
+}
@@ -0,0 +1,109 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_Pool(t *testing.T) {
+	tasks := make(chan func())
+	var done, concurrent, peak int32
+
+	wg := New(context.Background()).Pool(3, tasks)
+	if n := wg.Running(); n != 3 {
+		t.Fatalf("expect 3 workers, got %d", n)
+	}
+	for i := 0; i < 20; i++ {
+		tasks <- func() {
+			n := atomic.AddInt32(&concurrent, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&concurrent, -1)
+			atomic.AddInt32(&done, 1)
+		}
+	}
+	close(tasks)
+	wg.Wait()
+
+	if done != 20 {
+		t.Fatalf("expect 20 tasks done, got %d", done)
+	}
+	if peak > 3 {
+		t.Fatalf("expect at most 3 concurrent tasks, got %d", peak)
+	}
+}
+
+func TestWaitRoutine_PoolCancel(t *testing.T) {
+	tasks := make(chan func())
+	wg := New(context.Background()).Pool(2, tasks)
+	wg.Cancel()
+	if !finished(wg, time.Second) {
+		t.Fatal("expect workers exit on cancel without closing tasks")
+	}
+}
+
+func TestWaitRoutine_PoolLimited(t *testing.T) {
+	tasks := make(chan func())
+	var done int32
+	wg := NewWithLimit(context.Background(), 2).Pool(4, tasks)
+	sent := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			tasks <- func() { atomic.AddInt32(&done, 1) }
+		}
+		close(tasks)
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect tasks accepted by workers under a smaller limit")
+	}
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect workers exit after tasks closed")
+	}
+	if done != 10 {
+		t.Fatalf("expect 10 tasks done, got %d", done)
+	}
+}
+
+func TestWaitRoutine_PoolPanic(t *testing.T) {
+	tasks := make(chan func())
+	var done int32
+	wg := NewWithRecover(context.Background()).Pool(1, tasks)
+	for i := 0; i < 3; i++ {
+		tasks <- func() { panic("task") }
+		tasks <- func() { atomic.AddInt32(&done, 1) }
+	}
+	close(tasks)
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect worker exit after tasks closed")
+	}
+	if done != 3 {
+		t.Fatalf("expect worker survived panics, got %d tasks done", done)
+	}
+	if n := len(wg.Errors()); n != 3 {
+		t.Fatalf("expect 3 panics recorded, got %d", n)
+	}
+}
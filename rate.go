@@ -0,0 +1,77 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// SetRate 设置routine的启动速率,每秒最多启动r个,允许突发burst个
+//
+// 超过速率时Go()/GoRoutine()等调用在启动routine之前阻塞等待,
+// 等待时WaitRoutine被取消会立即停止等待并启动routine,routine会接收到ctx.Done()信号.
+// TryGo()超过速率时返回false.r为rate.Inf时不限制速率.
+// burst小于1时按1处理,否则任何routine都无法满足速率限制.
+// 未调用SetRate时不限制速率
+func (c *WaitRoutine) SetRate(r rate.Limit, burst int) *WaitRoutine {
+	if burst < 1 {
+		burst = 1
+	}
+	limiter := rate.NewLimiter(r, burst)
+	c.mu.Lock()
+	c.limiter = limiter
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// rateLimiter 返回通过SetRate()设置的速率限制,未设置时返回nil
+func (c *WaitRoutine) rateLimiter() *rate.Limiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limiter
+}
+
+// waitRate 等待速率限制,WaitRoutine被取消时立即返回
+//
+// 不使用limiter.Wait(),它在内部Context的deadline早于可以启动的时间时直接返回error而不等待,
+// 此时启动将完全不受速率限制
+func (c *WaitRoutine) waitRate() {
+	limiter := c.rateLimiter()
+	if limiter == nil {
+		return
+	}
+	// SetRate()保证burst至少为1,预约总是成功
+	r := limiter.Reserve()
+	delay := r.Delay()
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.Context().Done():
+		r.Cancel()
+	}
+}
+
+// allowRate 当前是否满足速率限制,不阻塞
+func (c *WaitRoutine) allowRate() bool {
+	limiter := c.rateLimiter()
+	return limiter == nil || limiter.Allow()
+}
@@ -0,0 +1,67 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestWaitRoutine_SetRate(t *testing.T) {
+	wg := New(context.Background())
+	wg.SetRate(rate.Every(50*time.Millisecond), 1)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		wg.Go(func() {})
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatalf("expect launches throttled, elapsed %v", elapsed)
+	}
+	if wg.TryGo(func() {}) {
+		t.Fatal("expect TryGo rejected by rate limit")
+	}
+
+	wg.SetRate(rate.Every(time.Hour), 1)
+	wg.Go(func() {})
+	done := make(chan struct{})
+	go func() {
+		wg.Go(func() {})
+		close(done)
+	}()
+	time.AfterFunc(50*time.Millisecond, wg.Cancel)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect Cancel unblocks throttled launch")
+	}
+	wg.Wait()
+}
+
+func TestWaitRoutine_SetRateZeroBurst(t *testing.T) {
+	wg := New(context.Background()).SetRate(rate.Every(50*time.Millisecond), 0)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		wg.Go(func() {})
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("expect launches throttled with burst clamped to 1, elapsed %v", elapsed)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import "errors"
+
+// 取消内部Context的原因,可以通过context.Cause(ctx)获取并使用errors.Is()判断
+var (
+	// ErrShutdown 正常退出时取消,比如WaitOrCancel()优雅退出超时
+	ErrShutdown = errors.New("waitroutine: shutdown")
+	// ErrTimeout 超过允许的运行时间时取消
+	ErrTimeout = errors.New("waitroutine: timeout")
+	// ErrRoutineFailed routine失败时取消,cancelOnError模式以routine的error本身为原因,
+	// 不使用ErrRoutineFailed,可以在自行判断routine失败时通过CancelReason()使用
+	ErrRoutineFailed = errors.New("waitroutine: routine failed")
+)
+
+// CancelReason 以reason为原因取消所有Routine运行
+//
+// 等同于CancelCause(reason),reason一般使用ErrShutdown/ErrTimeout/ErrRoutineFailed
+// 或者包装了它们的error,routine可以通过context.Cause(ctx)获取并判断取消的原因
+func (c *WaitRoutine) CancelReason(reason error) {
+	c.CancelCause(reason)
+}
+
+// CancelReason 通过默认WaitRoutine以reason为原因取消所有Routine运行
+func CancelReason(reason error) {
+	Default().CancelReason(reason)
+}
@@ -0,0 +1,49 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_CancelReason(t *testing.T) {
+	wg := New(context.Background())
+	cause := make(chan error, 1)
+	wg.GoRoutine(func(ctx context.Context) {
+		<-ctx.Done()
+		cause <- context.Cause(ctx)
+	})
+	wg.CancelReason(fmt.Errorf("deploy: %w", ErrShutdown))
+	wg.Wait()
+	if err := <-cause; !errors.Is(err, ErrShutdown) {
+		t.Fatalf("expect cause %v, got %v", ErrShutdown, err)
+	}
+	if !wg.Cancelled() {
+		t.Fatal("expect CancelReason marks the group cancelled")
+	}
+}
+
+func TestCancelReasonUsage(t *testing.T) {
+	wg := New(context.Background())
+	wg.GoRoutine(routine)
+	wg.WaitOrCancel(10*time.Millisecond, 0)
+	if err := wg.Cause(); !errors.Is(err, ErrShutdown) {
+		t.Fatalf("expect cause %v, got %v", ErrShutdown, err)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// ErrMaxRestarts 通过GoResilient()运行的routine重启次数超过RestartOptions.MaxRestarts
+var ErrMaxRestarts = errors.New("waitroutine: max restarts exceeded")
+
+// RestartOptions GoResilient()的重启策略
+type RestartOptions struct {
+	// MaxRestarts 最多重启次数,<=0时不限制
+	MaxRestarts int
+	// BaseBackoff 第一次重启前的等待时间,之后每次重启翻倍
+	BaseBackoff time.Duration
+	// MaxBackoff 重启前等待时间的上限,<=0时不限制
+	MaxBackoff time.Duration
+	// Jitter 等待时间的随机比例,取值[0, 1],实际等待时间在[d*(1-Jitter), d]之间均匀分布
+	Jitter float64
+	// ShouldRestart 判断是否重启,err为routine发生panic时的*PanicError,正常返回时为nil.
+	// 为nil时总是重启
+	ShouldRestart func(err error) bool
+}
+
+// backoff 返回第n次(从1开始)重启前的等待时间,r为[0, 1)之间的随机数
+func (o RestartOptions) backoff(n int, r float64) time.Duration {
+	d := o.BaseBackoff
+	for i := 1; i < n; i++ {
+		if d <= 0 || d > math.MaxInt64/2 || (o.MaxBackoff > 0 && d >= o.MaxBackoff) {
+			break
+		}
+		d *= 2
+	}
+	if o.MaxBackoff > 0 && d > o.MaxBackoff {
+		d = o.MaxBackoff
+	}
+	if jitter := o.Jitter; jitter > 0 {
+		if jitter > 1 {
+			jitter = 1
+		}
+		d -= time.Duration(float64(d) * jitter * r)
+	}
+	return d
+}
+
+// GoResilient 运行routine,routine发生panic或者返回后按opts重启,直到WaitRoutine被取消
+//
+// routine的panic总会被恢复,与OnPanic()相同记录为*PanicError并通知Logger和处理函数,
+// 但在重启之前不计入Errors().opts.ShouldRestart返回false时停止,此时panic的*PanicError作为最终error记录;
+// 重启次数超过opts.MaxRestarts时停止,以包装了ErrMaxRestarts和最后一次panic的error作为最终error记录.
+// 最终error与GoRoutineE()返回的error相同处理,重启次数计入Stats.Restarted
+func (c *WaitRoutine) GoResilient(routine Routine, opts RestartOptions) *WaitRoutine {
+	t := c.add("")
+	sem := c.acquire()
+	c.spawn(func() {
+		c.goRoutineE(t, sem, func(ctx context.Context) error {
+			return c.resilient(ctx, t, routine, opts)
+		})
+	})
+	return c
+}
+
+func (c *WaitRoutine) resilient(ctx context.Context, t *task, routine Routine, opts RestartOptions) error {
+	for restarts := 0; ; restarts++ {
+		err := c.runResilient(ctx, t, routine)
+		if ctx.Err() != nil {
+			return err
+		}
+		if opts.ShouldRestart != nil && !opts.ShouldRestart(err) {
+			return err
+		}
+		if opts.MaxRestarts > 0 && restarts >= opts.MaxRestarts {
+			if err == nil {
+				return fmt.Errorf("%w: %d", ErrMaxRestarts, opts.MaxRestarts)
+			}
+			return fmt.Errorf("%w: %d: %w", ErrMaxRestarts, opts.MaxRestarts, err)
+		}
+		if delay := opts.backoff(restarts+1, rand.Float64()); delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			}
+		}
+		atomic.AddUint64(&c.stats.Restarted, 1)
+	}
+}
+
+// runResilient 运行一次routine,发生panic时恢复并返回*PanicError
+func (c *WaitRoutine) runResilient(ctx context.Context, t *task, routine Routine) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = c.panicked(t, r)
+		}
+	}()
+	routine(ctx)
+	return nil
+}
+
+// GoResilient 通过默认WaitRoutine运行routine,routine发生panic或者返回后按opts重启
+func GoResilient(routine Routine, opts RestartOptions) *WaitRoutine {
+	return defaultRoutine().GoResilient(routine, opts)
+}
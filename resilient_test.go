@@ -0,0 +1,113 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRestartOptions_backoff(t *testing.T) {
+	opts := RestartOptions{BaseBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	for n, want := range map[int]time.Duration{
+		1:   10 * time.Millisecond,
+		2:   20 * time.Millisecond,
+		3:   40 * time.Millisecond,
+		4:   50 * time.Millisecond,
+		100: 50 * time.Millisecond,
+	} {
+		if got := opts.backoff(n, 0.5); got != want {
+			t.Fatalf("expect backoff(%d) = %v, got %v", n, want, got)
+		}
+	}
+
+	opts.Jitter = 0.5
+	if got := opts.backoff(2, 0); got != 20*time.Millisecond {
+		t.Fatalf("expect no reduction with r=0, got %v", got)
+	}
+	if got := opts.backoff(2, 0.5); got != 15*time.Millisecond {
+		t.Fatalf("expect 15ms with jitter 0.5 and r=0.5, got %v", got)
+	}
+	if got := opts.backoff(2, 0.999); got <= 10*time.Millisecond-time.Microsecond || got > 20*time.Millisecond {
+		t.Fatalf("expect jittered backoff within [10ms, 20ms], got %v", got)
+	}
+
+	opts = RestartOptions{BaseBackoff: time.Second}
+	if got := opts.backoff(200, 0); got <= 0 {
+		t.Fatalf("expect unbounded backoff not to overflow, got %v", got)
+	}
+	if got := (RestartOptions{}).backoff(5, 0.5); got != 0 {
+		t.Fatalf("expect zero backoff without BaseBackoff, got %v", got)
+	}
+}
+
+func TestWaitRoutine_GoResilient(t *testing.T) {
+	runs := 0
+	wg := New(context.Background())
+	wg.GoResilient(func(ctx context.Context) {
+		runs++
+		panic("unreliable")
+	}, RestartOptions{MaxRestarts: 3, BaseBackoff: time.Millisecond})
+	wg.Wait()
+
+	if runs != 4 {
+		t.Fatalf("expect 1 run and 3 restarts, got %d runs", runs)
+	}
+	if n := wg.Stats().Restarted; n != 3 {
+		t.Fatalf("expect 3 restarts, got %d", n)
+	}
+	if n := len(wg.Panics()); n != 4 {
+		t.Fatalf("expect 4 recorded panics, got %d", n)
+	}
+	errs := wg.Errors()
+	if len(errs) != 1 || !errors.Is(errs[0], ErrMaxRestarts) {
+		t.Fatalf("expect single terminal %v, got %v", ErrMaxRestarts, errs)
+	}
+	var pe *PanicError
+	if !errors.As(errs[0], &pe) || pe.Recovered != "unreliable" {
+		t.Fatalf("expect terminal error wraps the last panic, got %v", errs[0])
+	}
+}
+
+func TestWaitRoutine_GoResilientShouldRestart(t *testing.T) {
+	runs := 0
+	wg := New(context.Background())
+	wg.GoResilient(func(ctx context.Context) {
+		if runs++; runs == 2 {
+			panic("fatal")
+		}
+	}, RestartOptions{ShouldRestart: func(err error) bool { return err == nil }})
+	wg.Wait()
+
+	if runs != 2 {
+		t.Fatalf("expect restart after normal return only, got %d runs", runs)
+	}
+	var pe *PanicError
+	if err := wg.Err(); !errors.As(err, &pe) || pe.Recovered != "fatal" {
+		t.Fatalf("expect panic recorded as terminal error, got %v", err)
+	}
+
+	wg = New(context.Background())
+	wg.GoResilient(func(ctx context.Context) { <-ctx.Done() }, RestartOptions{})
+	wg.Cancel()
+	if !finished(wg, time.Second) {
+		t.Fatal("expect resilient routine stops on cancel")
+	}
+	if err := wg.Err(); err != nil {
+		t.Fatalf("expect nil error after cancel, got %v", err)
+	}
+}
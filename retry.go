@@ -0,0 +1,61 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// GoRetry 运行routine,routine返回error时重新运行,最多运行attempts次,attempts<=0时只运行1次
+//
+// 第n次运行失败后等待backoff(n)再重试,backoff为nil时立即重试.
+// 等待期间或者routine返回时内部Context已被取消则不再重试.
+// 最后一次运行的error(成功时为nil)像GoRoutineE()一样被记录,重试次数计入Stats.Restarted
+func (c *WaitRoutine) GoRetry(routine RoutineE, attempts int, backoff func(n int) time.Duration) *WaitRoutine {
+	if attempts <= 0 {
+		attempts = 1
+	}
+	return c.GoRoutineE(func(ctx context.Context) error {
+		return c.retry(ctx, routine, attempts, backoff)
+	})
+}
+
+func (c *WaitRoutine) retry(ctx context.Context, routine RoutineE, attempts int, backoff func(n int) time.Duration) error {
+	for n := 1; ; n++ {
+		err := routine(ctx)
+		if err == nil || n >= attempts || ctx.Err() != nil {
+			return err
+		}
+		if backoff != nil {
+			if delay := backoff(n); delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return err
+				}
+			}
+		}
+		atomic.AddUint64(&c.stats.Restarted, 1)
+	}
+}
+
+// GoRetry 通过默认WaitRoutine运行routine,routine返回error时最多重试到attempts次
+func GoRetry(routine RoutineE, attempts int, backoff func(n int) time.Duration) *WaitRoutine {
+	return defaultRoutine().GoRetry(routine, attempts, backoff)
+}
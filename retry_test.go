@@ -0,0 +1,88 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_GoRetry(t *testing.T) {
+	errFlaky := errors.New("flaky")
+
+	calls := 0
+	var delays []int
+	wg := New(context.Background())
+	wg.GoRetry(func(ctx context.Context) error {
+		if calls++; calls < 3 {
+			return errFlaky
+		}
+		return nil
+	}, 5, func(n int) time.Duration {
+		delays = append(delays, n)
+		return time.Millisecond
+	})
+	wg.Wait()
+	if calls != 3 {
+		t.Fatalf("expect 3 attempts, got %d", calls)
+	}
+	if len(delays) != 2 || delays[0] != 1 || delays[1] != 2 {
+		t.Fatalf("expect backoff(1), backoff(2), got %v", delays)
+	}
+	if err := wg.Err(); err != nil {
+		t.Fatalf("expect nil error after success, got %v", err)
+	}
+	if n := wg.Stats().Restarted; n != 2 {
+		t.Fatalf("expect 2 retries, got %d", n)
+	}
+
+	calls = 0
+	wg = New(context.Background())
+	wg.GoRetry(func(ctx context.Context) error {
+		calls++
+		return errFlaky
+	}, 3, nil)
+	wg.Wait()
+	if calls != 3 {
+		t.Fatalf("expect 3 attempts, got %d", calls)
+	}
+	if err := wg.Err(); err != errFlaky {
+		t.Fatalf("expect %v, got %v", errFlaky, err)
+	}
+}
+
+func TestWaitRoutine_GoRetryCancel(t *testing.T) {
+	errFlaky := errors.New("flaky")
+
+	calls := 0
+	wg := New(context.Background())
+	wg.GoRetry(func(ctx context.Context) error {
+		calls++
+		return errFlaky
+	}, 10, func(int) time.Duration { return time.Hour })
+	time.Sleep(50 * time.Millisecond)
+	wg.Cancel()
+	if !finished(wg, time.Second) {
+		t.Fatal("expect retry stops on cancel")
+	}
+	if calls != 1 {
+		t.Fatalf("expect 1 attempt, got %d", calls)
+	}
+	if err := wg.Err(); err != errFlaky {
+		t.Fatalf("expect %v, got %v", errFlaky, err)
+	}
+}
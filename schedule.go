@@ -0,0 +1,76 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"time"
+)
+
+// GoAfter 在d之后运行fn
+//
+// fn在调用时即被计入Wait()等待的routine,因此Wait()会等待到fn运行结束.
+// d之内WaitRoutine被取消时fn不会运行,计时器被释放.
+// 设置了并发限制时,fn在d之后才获取运行槽位,等待期间不占用槽位
+func (c *WaitRoutine) GoAfter(d time.Duration, fn func()) *WaitRoutine {
+	t := c.add("")
+	c.spawn(func() { c.goAfter(t, d, fn) })
+	return c
+}
+
+func (c *WaitRoutine) goAfter(t *task, d time.Duration, fn func()) {
+	timer := time.NewTimer(d)
+	select {
+	case <-timer.C:
+		c.goFn(t, c.acquire(), fn)
+	case <-t.ctx.Done():
+		timer.Stop()
+		c.done(t)
+	}
+}
+
+// GoEvery 每隔d运行一次fn,直到WaitRoutine被取消
+//
+// fn在同一个go routine中顺序运行,不会重叠.fn运行时间超过d时,期间错过的tick被丢弃,
+// fn返回后最多立即补运行一次(与time.Ticker行为一致).
+// WaitRoutine被取消后停止计时器并退出,正在运行的fn可以通过ctx.Done()提前返回.
+// d<=0时不运行fn
+func (c *WaitRoutine) GoEvery(d time.Duration, fn func(ctx context.Context)) *WaitRoutine {
+	if d <= 0 {
+		return c
+	}
+	return c.GoRoutine(func(ctx context.Context) {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fn(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// GoAfter 通过默认WaitRoutine在d之后运行fn
+func GoAfter(d time.Duration, fn func()) *WaitRoutine {
+	return defaultRoutine().GoAfter(d, fn)
+}
+
+// GoEvery 通过默认WaitRoutine每隔d运行一次fn,直到被取消
+func GoEvery(d time.Duration, fn func(ctx context.Context)) *WaitRoutine {
+	return defaultRoutine().GoEvery(d, fn)
+}
@@ -0,0 +1,75 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_GoAfter(t *testing.T) {
+	wg := New(context.Background())
+	start := time.Now()
+	var ranAfter time.Duration
+	wg.GoAfter(100*time.Millisecond, func() {
+		ranAfter = time.Since(start)
+	})
+	wg.Wait()
+	if ranAfter < 100*time.Millisecond {
+		t.Fatalf("expect fn ran after delay, ran after %v", ranAfter)
+	}
+
+	wg = New(context.Background())
+	ran := false
+	wg.GoAfter(time.Hour, func() {
+		ran = true
+	})
+	wg.Cancel()
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect delayed fn discarded after cancel")
+	}
+	if ran {
+		t.Fatal("expect fn not run after cancel")
+	}
+}
+
+func TestWaitRoutine_GoEvery(t *testing.T) {
+	var ticks int32
+	wg := NewWithTimeout(context.Background(), 550*time.Millisecond)
+	wg.GoEvery(100*time.Millisecond, func(ctx context.Context) {
+		atomic.AddInt32(&ticks, 1)
+	})
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect GoEvery stopped after cancel")
+	}
+	if n := atomic.LoadInt32(&ticks); n < 3 || n > 5 {
+		t.Fatalf("expect about 5 ticks, got %d", n)
+	}
+}
+
+func TestWaitRoutine_GoEveryNonPositive(t *testing.T) {
+	wg := NewWithRecover(context.Background())
+	wg.GoEvery(0, func(ctx context.Context) {})
+	wg.GoEvery(-time.Second, func(ctx context.Context) {})
+	if n := wg.Stats().Launched; n != 0 {
+		t.Fatalf("expect no routine launched for non-positive interval, got %d", n)
+	}
+	wg.Wait()
+	if errs := wg.Errors(); errs != nil {
+		t.Fatalf("expect no panic recorded, got %v", errs)
+	}
+}
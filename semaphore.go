@@ -0,0 +1,150 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// semaphore 限制同时运行routine个数的信号量
+//
+// 等待槽位的调用按优先级从高到低获取槽位,相同优先级按等待顺序获取.
+// 每次获取可以占用多个槽位(权重),队首的等待者槽位不足时后面的等待者同样需要等待,
+// 避免权重大的调用一直无法获取.
+// 等待队列为互斥锁保护的优先级堆,释放槽位时通过条件变量唤醒等待者
+type semaphore struct {
+	mu      sync.Mutex
+	cond    sync.Cond
+	size    int
+	used    int
+	seq     uint64
+	waiting semWaiters
+}
+
+// semWaiter 等待槽位的调用
+type semWaiter struct {
+	priority int
+	seq      uint64
+}
+
+// newSemaphore 新建一个最多有n个槽位的信号量
+func newSemaphore(n int) *semaphore {
+	s := &semaphore{size: n}
+	s.cond.L = &s.mu
+	return s
+}
+
+// tryAcquire 在有空闲槽位且没有等待者时获取一个槽位,不阻塞
+func (s *semaphore) tryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiting) == 0 && s.fits(1) {
+		s.used++
+		return true
+	}
+	return false
+}
+
+// weight 返回权重为n的调用实际需要占用的槽位个数,超过槽位总数时需要占用全部槽位
+func (s *semaphore) weight(n int) int {
+	if n > s.size {
+		return s.size
+	}
+	return n
+}
+
+// fits 返回当前空闲槽位是否足够权重为n的调用
+func (s *semaphore) fits(n int) bool {
+	return s.used+s.weight(n) <= s.size
+}
+
+// acquire 以priority优先级等待并获取权重为n的槽位,返回实际占用的槽位个数,
+// 需要以该个数调用release.queued在需要排队时于阻塞前调用
+func (s *semaphore) acquire(n, priority int, queued func()) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiting) == 0 && s.fits(n) {
+		n = s.weight(n)
+		s.used += n
+		return n
+	}
+	if queued != nil {
+		queued()
+	}
+	s.seq++
+	w := &semWaiter{priority: priority, seq: s.seq}
+	heap.Push(&s.waiting, w)
+	for s.waiting[0] != w || !s.fits(n) {
+		s.cond.Wait()
+	}
+	heap.Pop(&s.waiting)
+	n = s.weight(n)
+	s.used += n
+	// 仍有空闲槽位时让下一个等待者继续获取
+	s.cond.Broadcast()
+	return n
+}
+
+// resize 修改槽位个数为n并唤醒等待者
+//
+// 增大时等待者立即按顺序获取新增的槽位;减小到已占用个数以下时不影响已经占用槽位的调用,
+// 之后的获取需要等待占用个数降到n以下
+func (s *semaphore) resize(n int) {
+	s.mu.Lock()
+	s.size = n
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// limit 返回当前的槽位个数
+func (s *semaphore) limit() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// release 释放n个槽位
+func (s *semaphore) release(n int) {
+	s.mu.Lock()
+	s.used -= n
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// semWaiters 按优先级从高到低,相同优先级按等待顺序排列的堆
+type semWaiters []*semWaiter
+
+func (h semWaiters) Len() int { return len(h) }
+
+func (h semWaiters) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h semWaiters) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *semWaiters) Push(x interface{}) { *h = append(*h, x.(*semWaiter)) }
+
+func (h *semWaiters) Pop() interface{} {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return w
+}
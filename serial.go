@@ -0,0 +1,90 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync"
+)
+
+// NewSerial 新建一个串行运行routine的WaitRoutine,见SetSerial()
+func NewSerial(ctx context.Context) *WaitRoutine {
+	return New(ctx).SetSerial(true)
+}
+
+// SetSerial 设置是否串行运行routine
+//
+// 启用后Go()/GoRoutine()等不阻塞调用方,routine按提交顺序逐个运行,前一个结束后才运行下一个,
+// 用于不能同时运行但仍然以后台任务方式编写的任务.与SetLimit(1)不同,
+// 多个go routine同时等待槽位时SetLimit(1)不保证运行顺序,串行模式保证先进先出.
+// 同时运行的go routine提交时以进入队列的先后为准.Wait()在最后一个routine结束后返回.
+// routine中再运行的routine排在队列末尾;routine等待同一WaitRoutine中排在其后的routine会死锁.
+// 串行模式下通常不需要再设置并发限制,设置了SetSpawn()时用其启动运行队列的go routine.
+// 关闭时已经加入队列的routine仍然按顺序运行,只对之后启动的routine生效
+func (c *WaitRoutine) SetSerial(serial bool) *WaitRoutine {
+	c.mu.Lock()
+	switch {
+	case serial && c.serial == nil:
+		c.serial = &serialQueue{}
+	case !serial:
+		c.serial = nil
+	}
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// serialQueue 串行运行routine的先进先出队列
+//
+// 队列不为空时有一个go routine按顺序运行其中的routine,队列为空时该go routine退出
+type serialQueue struct {
+	mu      sync.Mutex
+	queue   []func()
+	running bool
+}
+
+// push 将f加入队列,没有运行队列的go routine时通过spawn或者go语句启动
+func (q *serialQueue) push(f func(), spawn func(f func())) {
+	q.mu.Lock()
+	q.queue = append(q.queue, f)
+	if q.running {
+		q.mu.Unlock()
+		return
+	}
+	q.running = true
+	q.mu.Unlock()
+	if spawn != nil {
+		spawn(q.drain)
+		return
+	}
+	go q.drain()
+}
+
+// drain 按顺序运行队列中的routine,直到队列为空
+func (q *serialQueue) drain() {
+	for {
+		q.mu.Lock()
+		if len(q.queue) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		f := q.queue[0]
+		q.queue[0] = nil
+		q.queue = q.queue[1:]
+		q.mu.Unlock()
+		f()
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewSerial(t *testing.T) {
+	const n = 50
+	var running, overlap int32
+	order := make(chan int, n)
+
+	wg := NewSerial(context.Background())
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Go(func() {
+			if atomic.AddInt32(&running, 1) > 1 {
+				atomic.StoreInt32(&overlap, 1)
+			}
+			time.Sleep(time.Millisecond)
+			order <- i
+			atomic.AddInt32(&running, -1)
+		})
+	}
+	wg.Wait()
+	close(order)
+
+	if overlap != 0 {
+		t.Fatal("expect serial routines not overlap")
+	}
+	want := 0
+	for i := range order {
+		if i != want {
+			t.Fatalf("expect routine %d run in submission order, got %d", want, i)
+		}
+		want++
+	}
+	if want != n {
+		t.Fatalf("expect %d routines run, got %d", n, want)
+	}
+}
+
+func TestWaitRoutine_SetSerialNested(t *testing.T) {
+	order := make(chan string, 3)
+	queued := make(chan struct{})
+	wg := NewSerial(context.Background())
+	wg.GoRoutine(func(ctx context.Context) {
+		<-queued
+		wg.Go(func() { order <- "nested" })
+		order <- "outer"
+	})
+	wg.Go(func() { order <- "next" })
+	close(queued)
+	if wg.WaitTimeout(5*time.Second) != WaitFinished {
+		t.Fatal("expect nested routine queued without deadlock")
+	}
+	close(order)
+
+	var got []string
+	for s := range order {
+		got = append(got, s)
+	}
+	if len(got) != 3 || got[0] != "outer" || got[1] != "next" || got[2] != "nested" {
+		t.Fatalf("expect nested routine queued at the end, got %v", got)
+	}
+
+	wg.SetSerial(false)
+	release := make(chan struct{})
+	wg.Go(func() { <-release })
+	done := make(chan struct{})
+	wg.Go(func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect routines run concurrently after serial mode disabled")
+	}
+	close(release)
+	wg.Wait()
+}
@@ -0,0 +1,65 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+// SetSpawnHook 设置每次启动routine所在的go routine之前同步调用的hook,hook为nil时取消
+//
+// hook在调用Go()等方法的go routine中,routine登记并获取运行槽位之后,启动go routine之前调用,
+// 测试中可以借此注入同步,使routine的启动顺序确定,而不需要依赖time.Sleep().
+// 只对之后启动的routine生效
+func (c *WaitRoutine) SetSpawnHook(hook func()) *WaitRoutine {
+	c.mu.Lock()
+	c.spawnHook = hook
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// SetSpawn 设置启动routine的函数,spawn为nil时恢复默认的go语句
+//
+// 每次启动routine时调用spawn(f),f包含routine的运行以及结束时的计数,spawn需要保证f被调用一次.
+// 测试中可以让spawn直接调用f以同步运行routine,或者在调用f之前注入延迟,panic等故障.
+// 同步运行时Go()等方法在routine结束后才返回,Wait()和各项计数仍然正确;
+// 设置了并发限制时,同步运行的routine中再调用Go()可能因为槽位被自身占用而死锁.
+// 只对之后启动的routine生效
+func (c *WaitRoutine) SetSpawn(spawn func(f func())) *WaitRoutine {
+	c.mu.Lock()
+	c.spawnFunc = spawn
+	c.updateFlags()
+	c.mu.Unlock()
+	return c
+}
+
+// spawn 通过SetSpawn()设置的函数或者go语句运行f,设置了SetSpawnHook()时先调用hook,
+// 启用了SetSerial()时将f加入串行队列
+func (c *WaitRoutine) spawn(f func()) {
+	if c.hasFlag(flagSpawn) {
+		c.mu.Lock()
+		hook, spawn, serial := c.spawnHook, c.spawnFunc, c.serial
+		c.mu.Unlock()
+		if hook != nil {
+			hook()
+		}
+		if serial != nil {
+			serial.push(f, spawn)
+			return
+		}
+		if spawn != nil {
+			spawn(f)
+			return
+		}
+	}
+	go f()
+}
@@ -0,0 +1,75 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_SetSpawnHook(t *testing.T) {
+	spawned := 0
+	started := make(chan int, 3)
+	wg := New(context.Background())
+	wg.SetSpawnHook(func() { spawned++ })
+	for i := 0; i < 3; i++ {
+		i := i
+		wg.Go(func() { started <- i })
+		if spawned != i+1 {
+			t.Fatalf("expect hook called synchronously before spawn %d, got %d", i, spawned)
+		}
+	}
+	wg.Wait()
+
+	wg.SetSpawnHook(nil)
+	wg.Go(func() {})
+	wg.Wait()
+	if spawned != 3 {
+		t.Fatalf("expect hook removed, got %d calls", spawned)
+	}
+}
+
+func TestWaitRoutine_SetSpawn(t *testing.T) {
+	var order []int
+	wg := New(context.Background()).SetSpawn(func(f func()) { f() })
+	wg.Go(func() { order = append(order, 1) }, func() { order = append(order, 2) })
+	order = append(order, 3)
+	if len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Fatalf("expect routines run synchronously in order, got %v", order)
+	}
+	if n := wg.Running(); n != 0 {
+		t.Fatalf("expect no running routine, got %d", n)
+	}
+	if wg.WaitTimeout(time.Second) != WaitFinished {
+		t.Fatal("expect Wait returns after synchronous routines")
+	}
+	if stats := wg.Stats(); stats.Launched != 2 || stats.Completed != 2 {
+		t.Fatalf("expect 2 launched and completed, got %+v", stats)
+	}
+
+	errInjected := errors.New("injected")
+	wg = NewWithRecover(context.Background()).SetSpawn(func(f func()) {
+		go func() {
+			time.Sleep(time.Millisecond)
+			f()
+		}()
+	})
+	wg.GoE(func() error { return errInjected })
+	if err := wg.WaitErr(); err != errInjected {
+		t.Fatalf("expect %v, got %v", errInjected, err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+// taskKind routine的运行方式,用于WaitSetup()等只等待部分routine
+type taskKind uint8
+
+const (
+	// kindOther 不单独计数的routine
+	kindOther taskKind = iota
+	// kindSetup 通过Go()运行的routine
+	kindSetup
+	// kindRoutine 通过GoRoutine()运行的routine
+	kindRoutine
+	numKinds
+)
+
+// stage 同一种运行方式的routine计数,由c.mu保护
+type stage struct {
+	running int
+	// ch 等待该运行方式的routine全部结束时创建,结束时关闭
+	ch chan struct{}
+}
+
+// finishStage 运行方式为kind的routine结束,需要持有c.mu
+func (c *WaitRoutine) finishStage(kind taskKind) {
+	if kind == kindOther {
+		return
+	}
+	s := &c.stages[kind]
+	s.running--
+	if s.running == 0 && s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
+
+// waitStage 等待运行方式为kind的routine全部结束
+func (c *WaitRoutine) waitStage(kind taskKind) {
+	c.mu.Lock()
+	s := &c.stages[kind]
+	if s.running == 0 {
+		c.mu.Unlock()
+		return
+	}
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	ch := s.ch
+	c.mu.Unlock()
+	<-ch
+}
+
+// WaitSetup 等待所有通过Go()运行的routine结束,不等待GoRoutine()等运行的routine
+//
+// 用于先通过Go()完成一次性的初始化,再通过GoRoutine()长期提供服务的场景:
+// 初始化完成后WaitSetup()返回,Wait()仍然等待所有routine
+func (c *WaitRoutine) WaitSetup() {
+	c.waitStage(kindSetup)
+}
+
+// WaitRoutines 等待所有通过GoRoutine()运行的routine结束,不等待Go()等运行的routine
+//
+// 与WaitSetup()对应,Wait()等待的是两者以及其他方式运行的所有routine
+func (c *WaitRoutine) WaitRoutines() {
+	c.waitStage(kindRoutine)
+}
+
+// WaitSetup 通过默认WaitRoutine等待所有通过Go()运行的routine结束
+func WaitSetup() {
+	Default().WaitSetup()
+}
+
+// WaitRoutines 通过默认WaitRoutine等待所有通过GoRoutine()运行的routine结束
+func WaitRoutines() {
+	Default().WaitRoutines()
+}
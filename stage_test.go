@@ -0,0 +1,66 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_WaitSetup(t *testing.T) {
+	var initialized int32
+	wg := New(context.Background())
+	wg.GoRoutine(routine)
+	wg.Go(func() {
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&initialized, 1)
+	}, func() {
+		atomic.AddInt32(&initialized, 1)
+	})
+
+	wg.WaitSetup()
+	if n := atomic.LoadInt32(&initialized); n != 2 {
+		t.Fatalf("expect setup funcs finished, got %d", n)
+	}
+	if n := wg.Running(); n != 1 {
+		t.Fatalf("expect server routine still running, got %d", n)
+	}
+	wg.WaitSetup()
+
+	wg.Cancel()
+	wg.Wait()
+}
+
+func TestWaitRoutine_WaitRoutines(t *testing.T) {
+	release := make(chan struct{})
+	wg := New(context.Background())
+	wg.Go(func() { <-release })
+	wg.GoRoutine(func(ctx context.Context) {}, func(ctx context.Context) {
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	wg.WaitRoutines()
+	if n := wg.Running(); n != 1 {
+		t.Fatalf("expect only the Go func still running, got %d", n)
+	}
+	if wg.WaitTimeout(20*time.Millisecond) != WaitTimedOut {
+		t.Fatal("expect Wait still waits for Go funcs")
+	}
+	close(release)
+	wg.WaitSetup()
+	wg.Wait()
+}
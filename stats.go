@@ -0,0 +1,266 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Stats WaitRoutine生命周期内累计的routine统计,各项只增不减
+type Stats struct {
+	// Launched 已启动的routine个数
+	Launched uint64
+	// Completed 已结束的routine个数,包括返回error和发生panic的routine
+	Completed uint64
+	// Panicked 发生panic并被恢复的routine个数
+	Panicked uint64
+	// Failed 返回非nil error的routine个数
+	Failed uint64
+	// Restarted 通过GoSupervised()等运行的routine被重启的次数
+	Restarted uint64
+	// Skipped 通过GoIfActive()/GoBefore()等跳过而没有运行的routine个数
+	Skipped uint64
+}
+
+// task 运行中routine的记录
+type task struct {
+	// id 启动序号,从1开始
+	id uint64
+	// name 名称,为空表示未命名
+	name string
+	// ctx 登记时WaitRoutine的内部Context,routine运行时使用
+	ctx context.Context
+	// group 登记时WaitRoutine的名称
+	group string
+	// kind 运行方式
+	kind taskKind
+	// onStart 开始运行时OnStart()注册的回调
+	onStart func(name string)
+	// onDone 开始运行时OnRoutineDone()注册的回调
+	onDone func(name string, err error, dur time.Duration)
+	// interceptor 开始运行时SetInterceptor()设置的包装函数
+	interceptor Interceptor
+	// err routine返回的error或者恢复的panic
+	err error
+	// added 登记的时间
+	added time.Time
+	// start 开始运行的时间,只在需要时记录
+	start time.Time
+	// logger 开始运行时设置的Logger
+	logger Logger
+	// durationMode 开始运行时设置的运行时间记录方式
+	durationMode DurationMode
+	// launch 启用SetCaptureLaunchStack()时登记routine的调用栈
+	launch []uintptr
+}
+
+// addN 一次登记n个即将运行的运行方式为kind的未命名routine,返回的task按启动顺序排列
+//
+// 所有routine在同一次加锁中登记,并发调用的Wait()不会在两个routine之间观察到运行个数为0.
+// 需要获取运行槽位时,除第一个外的routine在轮到其获取槽位之前同样计入Pending(),
+// 此时queued为true,每次获取前需要通过acquireBatch()扣除
+func (c *WaitRoutine) addN(n int, kind taskKind) (tasks []task, queued bool) {
+	if n <= 0 {
+		return nil, false
+	}
+	c.gate()
+	tasks = make([]task, n)
+	now := time.Now()
+	c.launched(now)
+	queued = n > 1 && c.hasFlag(flagAcquire)
+	launch := c.captureLaunch()
+	// 序号和计数与running在同一次加锁中更新,并发调用的Reset()不会在两者之间清零
+	c.mu.Lock()
+	atomic.AddUint64(&c.stats.Launched, uint64(n))
+	first := atomic.AddUint64(&c.lastID, uint64(n)) - uint64(n) + 1
+	atomic.AddInt32(&c.running, int32(n))
+	if queued {
+		atomic.AddInt32(&c.pending, int32(n-1))
+	}
+	if kind != kindOther {
+		c.stages[kind].running += n
+	}
+	if c.tasks == nil {
+		c.tasks = make(map[uint64]*task)
+	}
+	for i := range tasks {
+		t := &tasks[i]
+		t.id, t.added, t.ctx, t.group, t.kind = first+uint64(i), now, c.ctx, c.name, kind
+		t.launch = launch
+		c.tasks[t.id] = t
+	}
+	c.mu.Unlock()
+	c.wg.Add(n)
+	return tasks, queued
+}
+
+// acquireBatch 为addN()登记的第i个routine获取运行槽位
+func (c *WaitRoutine) acquireBatch(i int, queued bool) *semaphore {
+	if queued && i > 0 {
+		atomic.AddInt32(&c.pending, -1)
+	}
+	return c.acquire()
+}
+
+// add 登记一个即将运行的routine并计入Stats.Launched,name为空表示未命名
+func (c *WaitRoutine) add(name string) *task {
+	return c.register(name, true)
+}
+
+// register 登记一个routine,launched为false时不计入Stats.Launched,
+// 由调用者在确定运行时通过countLaunch()计入,被跳过时通过discard()撤销登记
+func (c *WaitRoutine) register(name string, launched bool) *task {
+	c.gate()
+	t := &task{name: name, added: time.Now()}
+	c.launched(t.added)
+	t.launch = c.captureLaunch()
+	c.mu.Lock()
+	if launched {
+		atomic.AddUint64(&c.stats.Launched, 1)
+	}
+	t.id = atomic.AddUint64(&c.lastID, 1)
+	t.ctx, t.group = c.ctx, c.name
+	atomic.AddInt32(&c.running, 1)
+	if c.tasks == nil {
+		c.tasks = make(map[uint64]*task)
+	}
+	c.tasks[t.id] = t
+	if name != "" {
+		if c.named == nil {
+			c.named = make(map[string]int)
+		}
+		c.named[name]++
+	}
+	c.mu.Unlock()
+	// 保持与WaitGroup()返回的sync.WaitGroup同步
+	c.wg.Add(1)
+	return t
+}
+
+// countLaunch 将通过register()登记且确定运行的routine计入Stats.Launched
+//
+// 登记期间running不为0,并发调用的Reset()不会清零计数
+func (c *WaitRoutine) countLaunch() {
+	atomic.AddUint64(&c.stats.Launched, 1)
+}
+
+// discard 撤销通过register()登记但最终没有运行的routine,不计入Stats.Launched和Stats.Completed
+//
+// 用于获取运行槽位之后才决定跳过的场景,比如GoIfActive()等待期间被取消
+func (c *WaitRoutine) discard(t *task) {
+	c.mu.Lock()
+	delete(c.tasks, t.id)
+	last := atomic.AddInt32(&c.running, -1) == 0
+	if last {
+		c.draining++
+	}
+	c.notifyWaiters(t.err, atomic.LoadUint64(&c.stats.Completed), last)
+	c.mu.Unlock()
+	if last {
+		c.drained()
+	}
+	c.wg.Done()
+}
+
+// begin 在routine所在的go routine中,routine开始运行前调用
+func (c *WaitRoutine) begin(t *task) {
+	if !c.hasFlag(flagHooks) {
+		return
+	}
+	c.mu.Lock()
+	t.logger = c.logger
+	t.durationMode = c.durationMode
+	t.onStart = c.onStart
+	t.onDone = c.onRoutineDone
+	t.interceptor = c.interceptor
+	c.mu.Unlock()
+	if t.logger != nil || t.durationMode != DurationNone || t.onDone != nil {
+		t.start = time.Now()
+	}
+	if t.logger != nil {
+		t.logger.RoutineStarted(t.Name())
+	}
+}
+
+// done 标记一个routine运行结束,需要通过defer调用以保证panic时计数正确
+func (c *WaitRoutine) done(t *task) {
+	completed := atomic.AddUint64(&c.stats.Completed, 1)
+	var dur time.Duration
+	if !t.start.IsZero() {
+		dur = time.Since(t.start)
+	}
+	if t.logger != nil {
+		t.logger.RoutineFinished(t.Name(), dur)
+	}
+	if t.onDone != nil {
+		t.onDone(t.Name(), t.err, dur)
+	}
+	c.mu.Lock()
+	if t.name != "" && c.unname(t.name) {
+		c.heartbeats.Delete(t.name)
+	}
+	c.recordDuration(t, dur)
+	delete(c.tasks, t.id)
+	c.finishStage(t.kind)
+	last := atomic.AddInt32(&c.running, -1) == 0
+	if last {
+		c.draining++
+	}
+	c.notifyWaiters(t.err, completed, last)
+	c.mu.Unlock()
+	if last {
+		c.drained()
+	}
+	c.wg.Done()
+}
+
+// runningTasks 返回当前登记的routine记录,按启动顺序排列
+func (c *WaitRoutine) runningTasks() []*task {
+	c.mu.Lock()
+	tasks := make([]*task, 0, len(c.tasks))
+	for _, t := range c.tasks {
+		tasks = append(tasks, t)
+	}
+	c.mu.Unlock()
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].id < tasks[j].id })
+	return tasks
+}
+
+// Running 返回当前正在运行(已启动但尚未结束)的routine个数
+//
+// 因并发限制等待运行槽位的routine不计入,其个数通过Pending()获取
+func (c *WaitRoutine) Running() int {
+	return int(atomic.LoadInt32(&c.running) - atomic.LoadInt32(&c.pending))
+}
+
+// Stats 返回routine累计统计,可以在routine运行时并发调用
+func (c *WaitRoutine) Stats() Stats {
+	return Stats{
+		Launched:  atomic.LoadUint64(&c.stats.Launched),
+		Completed: atomic.LoadUint64(&c.stats.Completed),
+		Panicked:  atomic.LoadUint64(&c.stats.Panicked),
+		Failed:    atomic.LoadUint64(&c.stats.Failed),
+		Restarted: atomic.LoadUint64(&c.stats.Restarted),
+		Skipped:   atomic.LoadUint64(&c.stats.Skipped),
+	}
+}
+
+// Running 通过默认WaitRoutine返回当前正在运行的routine个数
+func Running() int {
+	return Default().Running()
+}
@@ -0,0 +1,60 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWaitRoutine_Running(t *testing.T) {
+	release := make(chan struct{})
+	wg := NewWithRecover(context.Background())
+	wg.Go(func() {
+		<-release
+	}, func() {
+		<-release
+	}, func() {
+		<-release
+		panic("done")
+	})
+
+	if n := wg.Running(); n != 3 {
+		t.Fatalf("expect 3 running routines, got %d", n)
+	}
+	close(release)
+	wg.Wait()
+	if n := wg.Running(); n != 0 {
+		t.Fatalf("expect 0 running routines, got %d", n)
+	}
+}
+
+func TestWaitRoutine_Stats(t *testing.T) {
+	wg := NewWithRecover(context.Background())
+	wg.Go(func() {}, func() {
+		panic("stats")
+	}).GoE(func() error {
+		return errors.New("stats")
+	}, func() error {
+		return nil
+	})
+	wg.Wait()
+
+	expect := Stats{Launched: 4, Completed: 4, Panicked: 1, Failed: 1}
+	if stats := wg.Stats(); stats != expect {
+		t.Fatalf("expect stats %+v, got %+v", expect, stats)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import "sync/atomic"
+
+// Sub 创建一个子WaitRoutine,其内部Context从当前WaitRoutine的内部Context派生
+//
+// 取消父WaitRoutine会同时取消子WaitRoutine,子WaitRoutine可以单独Cancel()而不影响父WaitRoutine.
+// 子WaitRoutine继承panic恢复和cancelOnError设置.
+// 父WaitRoutine的Wait()只等待自身的routine,不等待子WaitRoutine中的routine,
+// 需要同时等待时使用WaitAll()
+func (c *WaitRoutine) Sub() *WaitRoutine {
+	sub := New(c.Context())
+	c.mu.Lock()
+	sub.recover = c.recover
+	sub.updateFlags()
+	sub.cancelOnError = c.cancelOnError
+	c.children = append(c.children, sub)
+	c.mu.Unlock()
+	return sub
+}
+
+// WaitAll 等待自身以及通过Sub()创建的所有子WaitRoutine(包括子WaitRoutine的子WaitRoutine)的routine运行结束
+//
+// 返回前不再跟踪已经没有routine运行的子WaitRoutine,避免反复Sub()时子WaitRoutine无限累积,
+// 之后在这些子WaitRoutine中运行的routine需要通过其自身的Wait()等待
+func (c *WaitRoutine) WaitAll() {
+	c.Wait()
+	c.mu.Lock()
+	children := make([]*WaitRoutine, len(c.children))
+	copy(children, c.children)
+	c.mu.Unlock()
+	for _, child := range children {
+		child.WaitAll()
+	}
+	c.mu.Lock()
+	c.pruneChildren()
+	c.mu.Unlock()
+}
+
+// pruneChildren 移除已经没有routine运行并且没有需要跟踪的子WaitRoutine的子WaitRoutine,需要持有c.mu
+func (c *WaitRoutine) pruneChildren() {
+	kept := c.children[:0]
+	for _, child := range c.children {
+		if child.busy() {
+			kept = append(kept, child)
+		}
+	}
+	for i := len(kept); i < len(c.children); i++ {
+		c.children[i] = nil
+	}
+	c.children = kept
+}
+
+// busy 是否仍有routine运行(包括等待运行槽位)或者仍有需要跟踪的子WaitRoutine
+func (c *WaitRoutine) busy() bool {
+	if atomic.LoadInt32(&c.running) != 0 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.children) != 0
+}
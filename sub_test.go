@@ -0,0 +1,85 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_Sub(t *testing.T) {
+	parent := New(context.Background())
+	child := parent.Sub()
+	child.GoRoutine(routine)
+
+	child.Cancel()
+	if !finished(child, 5*time.Second) {
+		t.Fatal("expect child routines stopped after child cancel")
+	}
+	if parent.Context().Err() != nil {
+		t.Fatal("expect parent not cancelled by child cancel")
+	}
+
+	child = parent.Sub()
+	grandchild := child.Sub()
+	child.GoRoutine(routine)
+	grandchild.GoRoutine(routine)
+	parent.Cancel()
+
+	done := make(chan struct{})
+	go func() {
+		parent.WaitAll()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect WaitAll returns after parent cancel")
+	}
+	if grandchild.Running() != 0 {
+		t.Fatal("expect WaitAll waited for grandchild routines")
+	}
+}
+
+func TestWaitRoutine_SubPruned(t *testing.T) {
+	parent := New(context.Background())
+	for i := 0; i < 10; i++ {
+		parent.Sub().Go(func() {}).Wait()
+	}
+	release := make(chan struct{})
+	busy := parent.Sub()
+	busy.Sub().Go(func() { <-release })
+	parent.Sub()
+
+	if err := parent.Reset(); err != nil {
+		t.Fatalf("expect parent reset, got %v", err)
+	}
+	parent.mu.Lock()
+	n := len(parent.children)
+	parent.mu.Unlock()
+	if n != 1 {
+		t.Fatalf("expect only the busy child kept after Reset, got %d", n)
+	}
+
+	close(release)
+	parent.WaitAll()
+	parent.mu.Lock()
+	n = len(parent.children)
+	parent.mu.Unlock()
+	if n != 0 {
+		t.Fatalf("expect drained children pruned after WaitAll, got %d", n)
+	}
+}
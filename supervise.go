@@ -0,0 +1,93 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// GoSupervised 运行routine,routine返回后立即重新运行,直到WaitRoutine被取消
+//
+// 等同于GoSupervisedBackoff(routine, 0, 0)
+func (c *WaitRoutine) GoSupervised(routine Routine) *WaitRoutine {
+	return c.GoSupervisedBackoff(routine, 0, 0)
+}
+
+// GoSupervisedBackoff 运行routine,routine返回后等待一段时间重新运行,直到WaitRoutine被取消
+//
+// 第一次重启等待minDelay,之后每次连续重启等待时间翻倍,最大为maxDelay;
+// routine单次运行时间超过maxDelay时认为其运行正常,等待时间恢复为minDelay.
+// 恢复panic时(NewWithRecover()或者OnPanic()),panic会被记录并同样重启routine.
+// 重启次数计入Stats.Restarted
+func (c *WaitRoutine) GoSupervisedBackoff(routine Routine, minDelay, maxDelay time.Duration) *WaitRoutine {
+	if maxDelay < minDelay {
+		maxDelay = minDelay
+	}
+	t := c.add("")
+	sem := c.acquire()
+	c.spawn(func() {
+		c.goRoutine(t, sem, func(ctx context.Context) {
+			c.supervise(ctx, t, routine, minDelay, maxDelay)
+		})
+	})
+	return c
+}
+
+func (c *WaitRoutine) supervise(ctx context.Context, t *task, routine Routine, minDelay, maxDelay time.Duration) {
+	delay := minDelay
+	for {
+		start := time.Now()
+		c.runSupervised(ctx, t, routine)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > maxDelay {
+			delay = minDelay
+		}
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+		atomic.AddUint64(&c.stats.Restarted, 1)
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// runSupervised 运行一次被监管的routine,需要恢复panic时在此恢复以便重启
+func (c *WaitRoutine) runSupervised(ctx context.Context, t *task, routine Routine) {
+	if c.recoverable() {
+		defer c.recoverPanic(t)
+	}
+	routine(ctx)
+}
+
+// GoSupervised 通过默认WaitRoutine运行routine,routine返回后立即重新运行
+func GoSupervised(routine Routine) *WaitRoutine {
+	return defaultRoutine().GoSupervised(routine)
+}
+
+// GoSupervisedBackoff 通过默认WaitRoutine运行routine,routine返回后等待一段时间重新运行
+func GoSupervisedBackoff(routine Routine, minDelay, maxDelay time.Duration) *WaitRoutine {
+	return defaultRoutine().GoSupervisedBackoff(routine, minDelay, maxDelay)
+}
@@ -0,0 +1,87 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_GoSupervised(t *testing.T) {
+	var runs int32
+	wg := NewWithRecover(context.Background())
+	wg.GoSupervised(func(ctx context.Context) {
+		switch atomic.AddInt32(&runs, 1) {
+		case 3:
+			panic("supervised")
+		case 5:
+			wg.Cancel()
+		}
+	})
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect supervised routine stopped after cancel")
+	}
+	if n := atomic.LoadInt32(&runs); n != 5 {
+		t.Fatalf("expect 5 runs, got %d", n)
+	}
+	if stats := wg.Stats(); stats.Restarted != 4 || stats.Panicked != 1 {
+		t.Fatalf("expect 4 restarts and 1 panic, got %+v", stats)
+	}
+}
+
+func TestWaitRoutine_GoSupervisedPanics(t *testing.T) {
+	var runs int32
+	wg := NewWithRecover(context.Background())
+	wg.GoSupervised(func(ctx context.Context) {
+		n := atomic.AddInt32(&runs, 1)
+		if n > 3 {
+			wg.Cancel()
+			return
+		}
+		panic(fmt.Sprintf("boom-%d", n))
+	})
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect supervised routine stopped after cancel")
+	}
+	errs := wg.Errors()
+	if len(errs) != 3 {
+		t.Fatalf("expect 3 errors, got %v", errs)
+	}
+	for i, err := range errs {
+		var pe *PanicError
+		if !errors.As(err, &pe) || pe.Recovered != fmt.Sprintf("boom-%d", i+1) {
+			t.Fatalf("expect error %d from boom-%d, got %v", i, i+1, err)
+		}
+	}
+}
+
+func TestWaitRoutine_GoSupervisedBackoff(t *testing.T) {
+	var runs int32
+	wg := NewWithTimeout(context.Background(), 350*time.Millisecond)
+	wg.GoSupervisedBackoff(func(ctx context.Context) {
+		atomic.AddInt32(&runs, 1)
+	}, 50*time.Millisecond, 200*time.Millisecond)
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect supervised routine stopped after timeout")
+	}
+	// 0ms, 50ms, 150ms, 350ms
+	if n := atomic.LoadInt32(&runs); n < 2 || n > 4 {
+		t.Fatalf("expect about 3 runs with backoff, got %d", n)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"time"
+)
+
+// NewWithDeadline 新建一个WaitRoutine,内部Context在t时刻被取消
+//
+// Cancel()仍然可以提前取消,Cancel()或者Wait()返回时会释放内部计时器
+func NewWithDeadline(parent context.Context, t time.Time) *WaitRoutine {
+	wgc := &WaitRoutine{deadline: t, created: time.Now()}
+	wgc.setParent(parent)
+	wgc.derive()
+	return wgc
+}
+
+// NewWithTimeout 新建一个WaitRoutine,内部Context在d之后被取消
+//
+// Cancel()仍然可以提前取消,Cancel()或者Wait()返回时会释放内部计时器
+func NewWithTimeout(parent context.Context, d time.Duration) *WaitRoutine {
+	wgc := &WaitRoutine{timeout: d, created: time.Now()}
+	wgc.setParent(parent)
+	wgc.derive()
+	return wgc
+}
+
+// NewWithMaxLifetime 新建一个WaitRoutine,d之后无论routine是否仍在运行都以ErrTimeout为原因取消
+//
+// 与NewWithTimeout()不同,到期时通过CancelCause(ErrTimeout)取消,Cancelled()返回true,
+// 可以作为保证WaitRoutine不会一直运行的兜底.
+// Wait()在到期之前返回时停止计时器,不会取消已经结束的WaitRoutine;Reset()重新开始计时
+func NewWithMaxLifetime(parent context.Context, d time.Duration) *WaitRoutine {
+	wgc := &WaitRoutine{lifetime: d, created: time.Now()}
+	wgc.setParent(parent)
+	wgc.derive()
+	return wgc
+}
+
+// startLifetime 设置了lifetime时启动计时器,需要持有c.mu
+func (c *WaitRoutine) startLifetime() {
+	if c.lifetime <= 0 {
+		return
+	}
+	c.lifeGen++
+	gen := c.lifeGen
+	c.lifeTimer = time.AfterFunc(c.lifetime, func() {
+		c.mu.Lock()
+		current := c.lifeGen == gen && c.lifeTimer != nil
+		c.mu.Unlock()
+		if current {
+			c.CancelReason(ErrTimeout)
+		}
+	})
+}
+
+// stopLifetime 停止lifetime计时器,需要持有c.mu
+func (c *WaitRoutine) stopLifetime() {
+	if c.lifeTimer != nil {
+		c.lifeTimer.Stop()
+		c.lifeTimer = nil
+	}
+}
+
+// withTimeout 返回一个Routine,运行时以d为超时时间派生routine的context
+func withTimeout(d time.Duration, routine Routine) Routine {
+	return func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		routine(ctx)
+	}
+}
+
+// GoRoutineTimeout 运行参数传递的routines,每个routine的context在d后超时
+//
+// 每个routine拥有独立的超时计时,从routine开始运行时计算,某个routine超时不影响其他routine.
+// Cancel()同样会取消这些routine
+func (c *WaitRoutine) GoRoutineTimeout(d time.Duration, routines ...Routine) *WaitRoutine {
+	for _, routine := range routines {
+		c.GoRoutine(withTimeout(d, routine))
+	}
+	return c
+}
+
+// GoTimeout 运行fn,fn接收的done在d后或者WaitRoutine被取消时关闭
+//
+// 用于不使用context的代码同样遵守超时,fn可以通过select检查done决定是否提前返回.
+// 与GoRoutineTimeout()相同,超时从fn开始运行时计算,fn提前返回时立即释放计时器
+func (c *WaitRoutine) GoTimeout(d time.Duration, fn func(done <-chan struct{})) *WaitRoutine {
+	return c.GoRoutine(withTimeout(d, func(ctx context.Context) {
+		fn(ctx.Done())
+	}))
+}
+
+// GoRoutineTimeout 通过默认WaitRoutine运行参数传递的routines,每个routine的context在d后超时
+func GoRoutineTimeout(d time.Duration, routines ...Routine) *WaitRoutine {
+	return defaultRoutine().GoRoutineTimeout(d, routines...)
+}
+
+// GoTimeout 通过默认WaitRoutine运行fn,fn接收的done在d后或者WaitRoutine被取消时关闭
+func GoTimeout(d time.Duration, fn func(done <-chan struct{})) *WaitRoutine {
+	return defaultRoutine().GoTimeout(d, fn)
+}
@@ -0,0 +1,112 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_GoRoutineTimeout(t *testing.T) {
+	wg := New(context.Background())
+	start := time.Now()
+	wg.GoRoutineTimeout(100*time.Millisecond, routine, func(ctx context.Context) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expect routine context has deadline")
+		}
+	})
+	if wg.WaitTimeout(5*time.Second) != WaitFinished {
+		t.Fatal("expect routines timed out")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("expect routines waited for timeout, elapsed %v", elapsed)
+	}
+	if wg.Context().Err() != nil {
+		t.Fatal("expect group context not cancelled by routine timeout")
+	}
+}
+
+func TestWaitRoutine_GoTimeout(t *testing.T) {
+	wg := New(context.Background())
+	start := time.Now()
+	wg.GoTimeout(50*time.Millisecond, func(done <-chan struct{}) { <-done })
+	if wg.WaitTimeout(5*time.Second) != WaitFinished {
+		t.Fatal("expect done closed after timeout")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expect fn waited for timeout, elapsed %v", elapsed)
+	}
+
+	wg.GoTimeout(time.Hour, func(done <-chan struct{}) { <-done })
+	wg.Cancel()
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect done closed on group cancel")
+	}
+}
+
+func TestNewWithTimeout(t *testing.T) {
+	wg := NewWithTimeout(context.Background(), 100*time.Millisecond)
+	if _, ok := wg.Context().Deadline(); !ok {
+		t.Fatal("expect group context has deadline")
+	}
+	wg.GoRoutine(routine)
+	if !finished(wg, 5*time.Second) {
+		t.Fatal("expect routines exit after group timeout")
+	}
+	if err := wg.Context().Err(); err != context.DeadlineExceeded {
+		t.Fatalf("expect %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	wg = NewWithDeadline(context.Background(), time.Now().Add(time.Hour))
+	wg.GoRoutine(routine)
+	wg.Cancel()
+	wg.Wait()
+	if err := wg.Context().Err(); err != context.Canceled {
+		t.Fatalf("expect %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestNewWithMaxLifetime(t *testing.T) {
+	wg := NewWithMaxLifetime(context.Background(), 50*time.Millisecond)
+	wg.GoRoutine(routine)
+	wg.Wait()
+	if !wg.Cancelled() {
+		t.Fatal("expect group cancelled after max lifetime")
+	}
+	if err := wg.Cause(); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expect cause %v, got %v", ErrTimeout, err)
+	}
+
+	wg = NewWithMaxLifetime(context.Background(), 50*time.Millisecond)
+	wg.Go(func() {})
+	wg.Wait()
+	time.Sleep(100 * time.Millisecond)
+	if wg.Cancelled() || wg.Context().Err() != nil {
+		t.Fatal("expect timer stopped after early Wait")
+	}
+
+	if err := wg.Reset(); err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+	if wg.WaitTimeout(time.Second) != WaitFinished || wg.Context().Err() != nil {
+		t.Fatal("expect fresh context after Reset")
+	}
+	<-wg.Context().Done()
+	if err := wg.Cause(); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expect lifetime restarted by Reset, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Uptime 返回WaitRoutine创建以来经过的时间
+//
+// 可以在健康检查接口中输出,或者用于判断长期运行的WaitRoutine是否需要重建.Reset()不会重新计时
+func (c *WaitRoutine) Uptime() time.Duration {
+	if c.created.IsZero() {
+		return 0
+	}
+	return time.Since(c.created)
+}
+
+// Age 返回第一次运行routine以来经过的时间,尚未运行过routine时返回0
+//
+// 与Uptime()不同,从第一次调用Go()等登记routine时开始计时,Reset()不会重新计时
+func (c *WaitRoutine) Age() time.Duration {
+	first := atomic.LoadInt64(&c.firstLaunch)
+	if first == 0 {
+		return 0
+	}
+	return time.Since(c.created) - time.Duration(first-1)
+}
+
+// launched 在登记routine时调用,第一次调用时记录now作为Age()的起点
+func (c *WaitRoutine) launched(now time.Time) {
+	if atomic.LoadInt64(&c.firstLaunch) != 0 {
+		return
+	}
+	atomic.CompareAndSwapInt64(&c.firstLaunch, 0, int64(now.Sub(c.created))+1)
+}
+
+// Uptime 返回默认WaitRoutine创建以来经过的时间
+func Uptime() time.Duration {
+	return Default().Uptime()
+}
+
+// Age 返回默认WaitRoutine第一次运行routine以来经过的时间
+func Age() time.Duration {
+	return Default().Age()
+}
@@ -0,0 +1,57 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_UptimeAge(t *testing.T) {
+	wg := New(context.Background())
+	if wg.Age() != 0 {
+		t.Fatalf("expect zero age before first launch, got %v", wg.Age())
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	var start sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < 10; i++ {
+		go wg.Go(func() { start.Wait() })
+	}
+	go wg.Go(func() {})
+	for wg.Stats().Launched != 11 {
+		time.Sleep(time.Millisecond)
+	}
+	start.Done()
+	wg.Wait()
+	time.Sleep(20 * time.Millisecond)
+
+	uptime, age := wg.Uptime(), wg.Age()
+	if uptime < 70*time.Millisecond {
+		t.Fatalf("expect uptime since New, got %v", uptime)
+	}
+	if age < 20*time.Millisecond || age > uptime-50*time.Millisecond {
+		t.Fatalf("expect age since first launch, got %v with uptime %v", age, uptime)
+	}
+	if err := wg.Reset(); err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+	if wg.Age() < age {
+		t.Fatal("expect Reset keeps the first launch time")
+	}
+}
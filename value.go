@@ -0,0 +1,55 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+)
+
+// contextValue 通过WithValue()添加的键值对
+type contextValue struct {
+	key, val interface{}
+}
+
+// WithValue 向内部Context添加键值对,之后运行的routine可以通过ctx.Value(key)获取val
+//
+// 多次调用会逐层添加,Reset()之后仍然保留.key的要求与context.WithValue()相同.
+// 只对之后开始运行的routine生效
+func (c *WaitRoutine) WithValue(key, val interface{}) *WaitRoutine {
+	c.mu.Lock()
+	c.values = append(c.values, contextValue{key: key, val: val})
+	c.ctx = context.WithValue(c.ctx, key, val)
+	c.mu.Unlock()
+	return c
+}
+
+// groupKey 内部Context中保存所属WaitRoutine的key
+type groupKey struct{}
+
+// FromContext 返回ctx所属的WaitRoutine,即routine接收的ctx由哪个WaitRoutine的内部Context派生
+//
+// 内部Context总是携带其所属的WaitRoutine,嵌套较深的routine可以借此在同一WaitRoutine中
+// 运行新的routine,而不需要层层传递WaitRoutine,比如爬虫为每个链接运行新的routine.
+// 递归运行routine时需要自行保证终止条件(比如限制深度或者对任务去重),否则routine会无限增长;
+// 设置了并发限制时,routine中调用Go()等待槽位可能因为所有槽位都被等待中的routine占用而死锁,
+// 应使用TryGo()或者不设置并发限制.
+// ctx不是由WaitRoutine派生时返回nil, false;通过Sub()派生时返回最近的子WaitRoutine
+func FromContext(ctx context.Context) (*WaitRoutine, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	c, ok := ctx.Value(groupKey{}).(*WaitRoutine)
+	return c, ok
+}
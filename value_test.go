@@ -0,0 +1,84 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"testing"
+)
+
+type testKey string
+
+func TestWaitRoutine_WithValue(t *testing.T) {
+	wg := New(context.Background())
+	wg.WithValue(testKey("request"), "r-1").WithValue(testKey("span"), "s-1")
+
+	check := func() {
+		wg.GoRoutine(func(ctx context.Context) {
+			if v := ctx.Value(testKey("request")); v != "r-1" {
+				t.Errorf("expect request r-1, got %v", v)
+			}
+			if v := ctx.Value(testKey("span")); v != "s-1" {
+				t.Errorf("expect span s-1, got %v", v)
+			}
+		})
+		wg.Wait()
+	}
+	check()
+
+	wg.Cancel()
+	if wg.Context().Err() != context.Canceled {
+		t.Fatal("expect Cancel still cancels value context")
+	}
+	if err := wg.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	check()
+}
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("expect no WaitRoutine in background context")
+	}
+
+	wg := New(context.Background())
+	var crawl func(ctx context.Context, depth int)
+	visited := make(chan int, 16)
+	crawl = func(ctx context.Context, depth int) {
+		visited <- depth
+		if depth == 3 {
+			return
+		}
+		group, ok := FromContext(ctx)
+		if !ok || group != wg {
+			t.Errorf("expect FromContext returns the running group")
+			return
+		}
+		group.GoRoutine(
+			func(ctx context.Context) { crawl(ctx, depth+1) },
+			func(ctx context.Context) { crawl(ctx, depth+1) },
+		)
+	}
+	wg.GoRoutine(func(ctx context.Context) { crawl(ctx, 0) })
+	wg.Wait()
+	if n := len(visited); n != 15 {
+		t.Fatalf("expect 15 routines visited, got %d", n)
+	}
+
+	sub := wg.Sub()
+	if c, _ := FromContext(sub.Context()); c != sub {
+		t.Fatal("expect FromContext returns the nearest sub group")
+	}
+}
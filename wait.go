@@ -0,0 +1,395 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// WaitResult WaitTimeout()的结果
+type WaitResult int
+
+const (
+	// WaitFinished 所有Routine已经结束
+	WaitFinished WaitResult = iota
+	// WaitTimedOut 等待超时,仍有Routine未结束
+	WaitTimedOut
+	// WaitCancelled 内部Context先被取消,仍有Routine未结束
+	WaitCancelled
+)
+
+// String 实现fmt.Stringer接口
+func (r WaitResult) String() string {
+	switch r {
+	case WaitFinished:
+		return "finished"
+	case WaitTimedOut:
+		return "timedout"
+	case WaitCancelled:
+		return "cancelled"
+	}
+	return "unknown"
+}
+
+// WaitTimeout 等待所有Routine运行结束,最多等待d,内部Context被取消时提前返回
+//
+// 所有Routine在d内结束时返回WaitFinished,超时返回WaitTimedOut;
+// 内部Context先被取消(Cancel(),父Context取消或者NewWithTimeout()到期等)时立即返回WaitCancelled,
+// 此时routine可能仍在退出中,需要等待其结束时再调用Wait()等.
+// 取消与结束同时发生时优先返回WaitFinished.WaitTimeout不会取消routine,
+// 可以根据返回值决定是否Cancel()或者记录仍未退出的routine.
+// 没有登记的routine(Empty()为true)时立即返回WaitFinished,不会创建计时器
+func (c *WaitRoutine) WaitTimeout(d time.Duration) WaitResult {
+	if c.Empty() {
+		return WaitFinished
+	}
+	done, ctx := c.Done(), c.Context()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return WaitFinished
+	case <-timer.C:
+		return WaitTimedOut
+	case <-ctx.Done():
+		select {
+		case <-done:
+			return WaitFinished
+		default:
+			return WaitCancelled
+		}
+	}
+}
+
+// waitFor 等待所有Routine运行结束,最多等待d,所有Routine在d内结束时返回true
+//
+// 与WaitTimeout()不同,内部Context被取消时继续等待
+func (c *WaitRoutine) waitFor(d time.Duration) bool {
+	if c.Empty() {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-c.Done():
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// WaitContext 等待所有Routine运行结束,或者ctx被取消
+//
+// 所有Routine结束时返回nil,ctx先被取消时返回ctx.Err().
+// ctx被取消只会停止等待,不会调用Cancel(),routine会继续运行
+func (c *WaitRoutine) WaitContext(ctx context.Context) error {
+	select {
+	case <-c.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitChan 等待所有Routine运行结束,或者abort被关闭(或者接收到值)
+//
+// 所有Routine结束时返回true,abort先触发时返回false,用于只提供退出channel而不使用context的接口.
+// 与WaitContext()相同,abort触发只会停止等待,不会调用Cancel().
+// 等待基于Done()返回的channel,不启动额外的go routine,任意结果返回后都不会遗留.
+// abort为nil时与Wait()相同一直等待
+func (c *WaitRoutine) WaitChan(abort <-chan struct{}) bool {
+	select {
+	case <-c.Done():
+		return true
+	case <-abort:
+		return false
+	}
+}
+
+// WaitProgress 等待所有Routine运行结束,期间每隔interval调用一次cb
+//
+// cb接收当前运行中的routine个数和已经结束的routine个数(Stats.Completed),
+// 所有Routine结束后停止计时并最后调用一次cb,之后WaitProgress返回.
+// interval<=0时只在结束时调用cb
+func (c *WaitRoutine) WaitProgress(interval time.Duration, cb func(running, completed int)) {
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+	loop:
+		for {
+			select {
+			case <-c.Done():
+				break loop
+			case <-ticker.C:
+				cb(c.Running(), int(c.Stats().Completed))
+			}
+		}
+	}
+	c.Wait()
+	cb(c.Running(), int(c.Stats().Completed))
+}
+
+// CancelAndWait 取消所有Routine运行并等待其结束
+func (c *WaitRoutine) CancelAndWait() {
+	c.Cancel()
+	c.Wait()
+}
+
+// CancelAndWaitTimeout 取消所有Routine运行并等待其结束,最多等待d
+//
+// 所有Routine在d内结束时返回true,否则返回false,可用于实现优雅退出超时后强制退出
+func (c *WaitRoutine) CancelAndWaitTimeout(d time.Duration) bool {
+	c.Cancel()
+	return c.waitFor(d)
+}
+
+// ShutdownResult WaitOrCancel()的结果
+type ShutdownResult int
+
+const (
+	// ShutdownGraceful 所有Routine在优雅退出时间内结束,没有调用Cancel()
+	ShutdownGraceful ShutdownResult = iota
+	// ShutdownCancelled 优雅退出超时后以ErrShutdown为原因取消,之后所有Routine结束
+	ShutdownCancelled
+	// ShutdownTimeout 取消后仍有Routine未能在强制退出时间内结束
+	ShutdownTimeout
+)
+
+// String 实现fmt.Stringer接口
+func (r ShutdownResult) String() string {
+	switch r {
+	case ShutdownGraceful:
+		return "graceful"
+	case ShutdownCancelled:
+		return "cancelled"
+	case ShutdownTimeout:
+		return "timeout"
+	}
+	return "unknown"
+}
+
+// WaitOrCancel 等待所有Routine运行结束,最多等待d,超时后以ErrShutdown为原因取消并继续等待
+//
+// hard>0时取消之后最多再等待hard,仍未结束返回ShutdownTimeout,routine会继续运行;
+// hard<=0时取消之后一直等待到所有Routine结束.
+// 实现先优雅退出,超时后强制退出的流程
+func (c *WaitRoutine) WaitOrCancel(d, hard time.Duration) ShutdownResult {
+	if c.waitFor(d) {
+		c.Wait()
+		return ShutdownGraceful
+	}
+	c.CancelReason(ErrShutdown)
+	if hard <= 0 {
+		c.Wait()
+		return ShutdownCancelled
+	}
+	if !c.waitFor(hard) {
+		return ShutdownTimeout
+	}
+	c.Wait()
+	return ShutdownCancelled
+}
+
+// waiter 通过WaitN()等待的调用者
+type waiter struct {
+	// target Stats.Completed达到target时唤醒
+	target uint64
+	ch     chan struct{}
+	// err 唤醒waiter的routine的error
+	err error
+	// completed 唤醒时的Stats.Completed
+	completed uint64
+}
+
+// got 返回唤醒时自start之后结束的routine个数,最多为n
+//
+// 多个routine同时结束时唤醒的Stats.Completed可能超过target
+func (w *waiter) got(start uint64, n int) int {
+	if got := int(w.completed - start); got < n {
+		return got
+	}
+	return n
+}
+
+// notifyWaiters 在routine结束时唤醒满足条件的waiter,err为该routine的error,需要持有c.mu
+//
+// drained为true表示已经没有运行中的routine,此时唤醒所有waiter
+func (c *WaitRoutine) notifyWaiters(err error, completed uint64, drained bool) {
+	if len(c.waiters) == 0 {
+		return
+	}
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if drained || completed >= w.target {
+			w.err, w.completed = err, completed
+			close(w.ch)
+			continue
+		}
+		waiters = append(waiters, w)
+	}
+	for i := len(waiters); i < len(c.waiters); i++ {
+		c.waiters[i] = nil
+	}
+	c.waiters = waiters
+}
+
+// closedWaiter 不需要等待时waitN()返回的waiter
+var closedWaiter = &waiter{ch: closedChan}
+
+// waitN 返回一个在调用之后有n个routine结束时唤醒的waiter
+func (c *WaitRoutine) waitN(n int) *waiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n <= 0 || atomic.LoadInt32(&c.running) == 0 {
+		return closedWaiter
+	}
+	w := &waiter{
+		target: atomic.LoadUint64(&c.stats.Completed) + uint64(n),
+		ch:     make(chan struct{}),
+	}
+	c.waiters = append(c.waiters, w)
+	return w
+}
+
+// removeWaiter 移除尚未被唤醒的waiter,需要持有c.mu
+func (c *WaitRoutine) removeWaiter(w *waiter) {
+	for i, v := range c.waiters {
+		if v == w {
+			copy(c.waiters[i:], c.waiters[i+1:])
+			c.waiters[len(c.waiters)-1] = nil
+			c.waiters = c.waiters[:len(c.waiters)-1]
+			return
+		}
+	}
+}
+
+// WaitN 等待调用之后任意n个routine运行结束
+//
+// 适用于quorum场景,比如同时发出5个请求,3个完成即可继续,之后可以Cancel()其余routine.
+// 调用之后新运行的routine结束同样计入.n大于运行中的routine个数时,
+// 所有routine结束即返回,与Wait()效果相同.n<=0或者没有运行中的routine时立即返回
+func (c *WaitRoutine) WaitN(n int) {
+	<-c.waitN(n).ch
+}
+
+// WaitNTimeout 等待调用之后任意n个routine运行结束,最多等待d
+//
+// 返回d内结束的routine个数got(最多为n),以及是否超时.适用于有截止时间的quorum场景,
+// 比如等待至少3个副本确认,但最多等待200ms.第n个routine恰好在到期时结束时,
+// 只要其结束已经计入Stats.Completed,就返回n, false.
+// 与WaitN()相同,所有routine结束时即使不足n个也立即返回,此时timedOut为false.
+// n<=0或者没有运行中的routine时立即返回0, false
+func (c *WaitRoutine) WaitNTimeout(n int, d time.Duration) (got int, timedOut bool) {
+	w := c.waitN(n)
+	if w == closedWaiter {
+		return 0, false
+	}
+	start := w.target - uint64(n)
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-w.ch:
+		return w.got(start, n), false
+	case <-timer.C:
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-w.ch:
+		return w.got(start, n), false
+	default:
+	}
+	c.removeWaiter(w)
+	// 已经计入Completed但尚未唤醒waiter的routine同样算作在期限内结束
+	if got = int(atomic.LoadUint64(&c.stats.Completed) - start); got >= n {
+		return n, false
+	}
+	return got, true
+}
+
+// WaitAny 等待调用之后任意一个routine运行结束,等同于WaitN(1)
+//
+// 适用于竞速场景,取最先完成的结果,之后通常调用Cancel()取消其余routine
+func (c *WaitRoutine) WaitAny() {
+	c.WaitN(1)
+}
+
+// WaitAnyE 等待调用之后任意一个routine运行结束,返回该routine的error
+//
+// 通过GoE()/GoRoutineE()运行的routine返回的error,或者恢复panic时的*PanicError.
+// 没有运行中的routine时立即返回nil
+func (c *WaitRoutine) WaitAnyE() error {
+	w := c.waitN(1)
+	<-w.ch
+	return w.err
+}
+
+// WaitTimeout 通过默认WaitRoutine等待所有Routine运行结束,最多等待d,内部Context被取消时提前返回
+func WaitTimeout(d time.Duration) WaitResult {
+	return Default().WaitTimeout(d)
+}
+
+// WaitContext 通过默认WaitRoutine等待所有Routine运行结束,或者ctx被取消
+func WaitContext(ctx context.Context) error {
+	return Default().WaitContext(ctx)
+}
+
+// WaitChan 通过默认WaitRoutine等待所有Routine运行结束,或者abort被关闭
+func WaitChan(abort <-chan struct{}) bool {
+	return Default().WaitChan(abort)
+}
+
+// WaitProgress 通过默认WaitRoutine等待所有Routine运行结束,期间每隔interval调用一次cb
+func WaitProgress(interval time.Duration, cb func(running, completed int)) {
+	Default().WaitProgress(interval, cb)
+}
+
+// CancelAndWait 通过默认WaitRoutine取消所有Routine运行并等待其结束
+func CancelAndWait() {
+	Default().CancelAndWait()
+}
+
+// CancelAndWaitTimeout 通过默认WaitRoutine取消所有Routine运行并等待其结束,最多等待d
+func CancelAndWaitTimeout(d time.Duration) bool {
+	return Default().CancelAndWaitTimeout(d)
+}
+
+// WaitOrCancel 通过默认WaitRoutine等待所有Routine运行结束,超时后取消并继续等待
+func WaitOrCancel(d, hard time.Duration) ShutdownResult {
+	return Default().WaitOrCancel(d, hard)
+}
+
+// WaitN 通过默认WaitRoutine等待调用之后任意n个routine运行结束
+func WaitN(n int) {
+	Default().WaitN(n)
+}
+
+// WaitNTimeout 通过默认WaitRoutine等待调用之后任意n个routine运行结束,最多等待d
+func WaitNTimeout(n int, d time.Duration) (got int, timedOut bool) {
+	return Default().WaitNTimeout(n, d)
+}
+
+// WaitAny 通过默认WaitRoutine等待调用之后任意一个routine运行结束
+func WaitAny() {
+	Default().WaitAny()
+}
+
+// WaitAnyE 通过默认WaitRoutine等待调用之后任意一个routine运行结束,返回该routine的error
+func WaitAnyE() error {
+	return Default().WaitAnyE()
+}
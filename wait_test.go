@@ -0,0 +1,323 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_WaitTimeout(t *testing.T) {
+	release := make(chan struct{})
+	wg := New(context.Background())
+	wg.GoRoutine(routine)
+	wg.Go(func() { <-release })
+
+	if r := wg.WaitTimeout(100 * time.Millisecond); r != WaitTimedOut {
+		t.Fatalf("expect %v, got %v", WaitTimedOut, r)
+	}
+	wg.Cancel()
+	start := time.Now()
+	if r := wg.WaitTimeout(5 * time.Second); r != WaitCancelled {
+		t.Fatalf("expect %v, got %v", WaitCancelled, r)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("expect WaitTimeout returns promptly on cancel, took %v", d)
+	}
+	close(release)
+	wg.Wait()
+	if r := wg.WaitTimeout(5 * time.Second); r != WaitFinished {
+		t.Fatalf("expect %v after routines finished, got %v", WaitFinished, r)
+	}
+
+	wg = NewWithTimeout(context.Background(), 50*time.Millisecond)
+	wg.Go(func() { time.Sleep(time.Second) })
+	if r := wg.WaitTimeout(5 * time.Second); r != WaitCancelled {
+		t.Fatalf("expect %v on group deadline, got %v", WaitCancelled, r)
+	}
+	wg.Wait()
+
+	for r, want := range map[WaitResult]string{
+		WaitFinished:   "finished",
+		WaitTimedOut:   "timedout",
+		WaitCancelled:  "cancelled",
+		WaitResult(-1): "unknown",
+	} {
+		if r.String() != want {
+			t.Fatalf("expect %q, got %q", want, r.String())
+		}
+	}
+}
+
+func TestWaitRoutine_WaitProgress(t *testing.T) {
+	wg := New(context.Background())
+	for i := 1; i <= 3; i++ {
+		d := time.Duration(i) * 30 * time.Millisecond
+		wg.Go(func() { time.Sleep(d) })
+	}
+
+	calls := 0
+	lastRunning, lastCompleted := -1, -1
+	wg.WaitProgress(10*time.Millisecond, func(running, completed int) {
+		calls++
+		lastRunning, lastCompleted = running, completed
+	})
+	if calls < 2 {
+		t.Fatalf("expect periodic callbacks, got %d", calls)
+	}
+	if lastRunning != 0 || lastCompleted != 3 {
+		t.Fatalf("expect final callback (0, 3), got (%d, %d)", lastRunning, lastCompleted)
+	}
+}
+
+func TestWaitRoutine_WaitTimeoutEmpty(t *testing.T) {
+	wg := New(context.Background())
+	if !wg.Empty() {
+		t.Fatal("expect fresh group empty")
+	}
+	start := time.Now()
+	if wg.WaitTimeout(time.Hour) != WaitFinished {
+		t.Fatal("expect WaitTimeout true on an empty group")
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Fatalf("expect WaitTimeout returns instantly on an empty group, took %v", d)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { wg.WaitTimeout(time.Hour) }); allocs != 0 {
+		t.Fatalf("expect no timer allocated on an empty group, got %v allocs", allocs)
+	}
+
+	release := make(chan struct{})
+	wg.Go(func() { <-release })
+	if wg.Empty() {
+		t.Fatal("expect group with a running routine not empty")
+	}
+	close(release)
+	wg.Wait()
+	if !wg.Empty() {
+		t.Fatal("expect group empty after all routines finish")
+	}
+}
+
+func TestWaitRoutine_WaitContext(t *testing.T) {
+	wg := New(context.Background())
+	wg.GoRoutine(routine)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := wg.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expect %v, got %v", context.DeadlineExceeded, err)
+	}
+	if wg.Context().Err() != nil {
+		t.Fatal("expect group context not cancelled by WaitContext")
+	}
+
+	wg.Cancel()
+	if err := wg.WaitContext(context.Background()); err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+}
+
+func TestWaitRoutine_WaitChan(t *testing.T) {
+	before := runtime.NumGoroutine()
+	release := make(chan struct{})
+	wg := New(context.Background())
+	wg.Go(func() { <-release })
+
+	abort := make(chan struct{})
+	close(abort)
+	if wg.WaitChan(abort) {
+		t.Fatal("expect false when abort fires first")
+	}
+	if wg.Context().Err() != nil {
+		t.Fatal("expect group context not cancelled by WaitChan")
+	}
+	close(release)
+	if !wg.WaitChan(make(chan struct{})) {
+		t.Fatal("expect true when routines finish")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect no goroutine left behind, got %d before %d after", before, runtime.NumGoroutine())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWaitRoutine_CancelAndWaitTimeout(t *testing.T) {
+	wg := New(context.Background())
+	wg.GoRoutine(routine, routine)
+	if !wg.CancelAndWaitTimeout(5 * time.Second) {
+		t.Fatal("expect routines stopped after cancel")
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	wg = New(context.Background())
+	wg.Go(func() {
+		<-release
+	})
+	if wg.CancelAndWaitTimeout(100 * time.Millisecond) {
+		t.Fatal("expect routine ignoring cancel timed out")
+	}
+}
+
+func TestWaitRoutine_WaitNTimeout(t *testing.T) {
+	release := make(chan struct{})
+	wg := New(context.Background())
+	for i := 0; i < 5; i++ {
+		d := time.Duration(i) * 30 * time.Millisecond
+		if i >= 3 {
+			wg.Go(func() { <-release })
+			continue
+		}
+		wg.Go(func() { time.Sleep(d) })
+	}
+
+	if got, timedOut := wg.WaitNTimeout(2, 5*time.Second); got != 2 || timedOut {
+		t.Fatalf("expect 2 completed without timeout, got %d %t", got, timedOut)
+	}
+	if got, timedOut := wg.WaitNTimeout(2, 100*time.Millisecond); got != 1 || !timedOut {
+		t.Fatalf("expect 1 completed before timeout, got %d %t", got, timedOut)
+	}
+	wg.mu.Lock()
+	n := len(wg.waiters)
+	wg.mu.Unlock()
+	if n != 0 {
+		t.Fatalf("expect timed out waiter removed, got %d", n)
+	}
+	close(release)
+	if got, timedOut := wg.WaitNTimeout(5, 5*time.Second); got != 2 || timedOut {
+		t.Fatalf("expect all remaining routines completed, got %d %t", got, timedOut)
+	}
+	if got, timedOut := wg.WaitNTimeout(1, time.Second); got != 0 || timedOut {
+		t.Fatalf("expect immediate return without running routines, got %d %t", got, timedOut)
+	}
+
+	for i := 0; i < 100; i++ {
+		wg.Go(func() {})
+		got, timedOut := wg.WaitNTimeout(1, 0)
+		if timedOut == (got == 1) {
+			t.Fatalf("expect consistent result at the deadline, got %d %t", got, timedOut)
+		}
+	}
+	wg.Wait()
+}
+
+func TestWaitRoutine_WaitN(t *testing.T) {
+	wg := New(context.Background())
+	for i := 1; i <= 5; i++ {
+		d := time.Duration(i) * 20 * time.Millisecond
+		wg.GoRoutine(func(ctx context.Context) {
+			select {
+			case <-time.After(d):
+			case <-ctx.Done():
+			}
+		})
+	}
+	wg.WaitN(3)
+	if n := wg.Stats().Completed; n < 3 {
+		t.Fatalf("expect at least 3 completed routines, got %d", n)
+	}
+	wg.CancelAndWait()
+
+	wg.Go(func() {})
+	done := make(chan struct{})
+	go func() {
+		wg.WaitN(10)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect WaitN returns after all routines finished")
+	}
+}
+
+func TestWaitRoutine_WaitAnyE(t *testing.T) {
+	errFastest := errors.New("fastest")
+
+	wg := New(context.Background())
+	wg.GoRoutine(routine)
+	wg.GoRoutineE(func(ctx context.Context) error {
+		<-time.After(50 * time.Millisecond)
+		return errFastest
+	})
+	if err := wg.WaitAnyE(); err != errFastest {
+		t.Fatalf("expect %v, got %v", errFastest, err)
+	}
+	if n := wg.Running(); n != 1 {
+		t.Fatalf("expect 1 running routine, got %d", n)
+	}
+	wg.CancelAndWait()
+
+	wg.GoRoutine(routine)
+	go func() {
+		<-time.After(50 * time.Millisecond)
+		wg.Cancel()
+	}()
+	wg.WaitAny()
+	if err := wg.WaitAnyE(); err != nil {
+		t.Fatalf("expect nil error without running routines, got %v", err)
+	}
+}
+
+func TestWaitRoutine_WaitTimeoutNoHelper(t *testing.T) {
+	release := make(chan struct{})
+	wg := New(context.Background())
+	wg.Go(func() { <-release })
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		wg.WaitTimeout(time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("expect no helper goroutines left by WaitTimeout, %d before, %d after", before, after)
+	}
+	close(release)
+	wg.Wait()
+}
+
+func TestWaitRoutine_WaitOrCancel(t *testing.T) {
+	wg := New(context.Background())
+	wg.Go(func() {})
+	if r := wg.WaitOrCancel(time.Second, 0); r != ShutdownGraceful {
+		t.Fatalf("expect %v, got %v", ShutdownGraceful, r)
+	}
+	if wg.Cancelled() {
+		t.Fatal("expect no Cancel on graceful shutdown")
+	}
+
+	wg = New(context.Background())
+	wg.GoRoutine(routine)
+	if r := wg.WaitOrCancel(20*time.Millisecond, time.Second); r != ShutdownCancelled {
+		t.Fatalf("expect %v, got %v", ShutdownCancelled, r)
+	}
+	if !wg.Cancelled() {
+		t.Fatal("expect Cancel after graceful timeout")
+	}
+
+	release := make(chan struct{})
+	wg = New(context.Background())
+	wg.Go(func() { <-release })
+	if r := wg.WaitOrCancel(10*time.Millisecond, 10*time.Millisecond); r != ShutdownTimeout {
+		t.Fatalf("expect %v, got %v", ShutdownTimeout, r)
+	}
+	close(release)
+	wg.Wait()
+}
@@ -19,156 +19,610 @@
 //
 // 典型使用场景如下,通过WaitRoutine.Go()运行某些特定功能的go routine,并等待其退出.
 //
-//  wg := waitroutine.New(nil)
-//  wg.Go(func() {
-//     // do something
-//  }).Go(func() {
-//     // do other something
-//  })
-//  wg.Wait()
+//	wg := waitroutine.New(nil)
+//	wg.Go(func() {
+//	   // do something
+//	}).Go(func() {
+//	   // do other something
+//	})
+//	wg.Wait()
 //
 // 也可以通过接收到某种信号进行Cancel(),通过WaitRoutine.GoRoutine()运行一个持久
 // 运行类型为Routine的go routine,在满足特定条件时,退出.
 // 这个特定条件可以是ctx传递进去,也可以是特定功能运行结束.如下通过ctx.Done()退出:
-//  routine := func(ctx context.Context) {
-//    tick := time.NewTicker(time.Second)
-//    for {
-//      select {
-//      case <-tick.C:
-//      case <-ctx.Done():
-//    	  return
-//      }
-//    }
-//  }
-//  wg := New(context.Background())
-//  time.AfterFunc(waitSecond, func() {
-//     fmt.Println("it's time to cancel")
-//     wg.Cancel()
-//  })
-//
-//  wg.GoRoutine(routine)
-//  wg.GoRoutine(routine)
-//
-//  wg.Wait()
 //
+//	routine := func(ctx context.Context) {
+//	  tick := time.NewTicker(time.Second)
+//	  for {
+//	    select {
+//	    case <-tick.C:
+//	    case <-ctx.Done():
+//	  	  return
+//	    }
+//	  }
+//	}
+//	wg := New(context.Background())
+//	time.AfterFunc(waitSecond, func() {
+//	   fmt.Println("it's time to cancel")
+//	   wg.Cancel()
+//	})
+//
+//	wg.GoRoutine(routine)
+//	wg.GoRoutine(routine)
+//
+//	wg.Wait()
+//
+// 包级别的Go(),Wait()等函数使用默认WaitRoutine,通过Default()获取.
+// 原有的包级别变量DefaultWaitRoutine已经移除,默认WaitRoutine改为在第一次使用时创建,
+// 这是一个不兼容的修改,原有代码按如下方式迁移:
+//
+//	waitroutine.DefaultWaitRoutine.Cancel()          // 改为 waitroutine.Default().Cancel()
+//	waitroutine.DefaultWaitRoutine = waitroutine.New(ctx) // 改为 waitroutine.SetDefault(waitroutine.New(ctx))
+//
+// 测试之间可以通过ResetDefault()丢弃默认WaitRoutine
 package waitroutine
 
 import (
 	"context"
+	"errors"
+	"runtime/pprof"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
+// ErrRunning 仍有routine运行时调用Reset()等方法返回的error
+var ErrRunning = errors.New("waitroutine: routines are still running")
+
 // Routine 可以通过Go()函数运行的routine原型
 type Routine func(ctx context.Context)
 
 // WaitRoutine 管理go routine
 type WaitRoutine struct {
+	// stats 通过atomic访问,保持为第一个字段以保证64位对齐
+	stats Stats
+
+	// wg 只用于兼容WaitGroup(),等待通过running计数和doneCh实现
 	wg         sync.WaitGroup
+	parent     context.Context
 	ctx        context.Context
-	cancelFunc context.CancelFunc
+	cancelFunc context.CancelCauseFunc
+	// deadline/timeout 由NewWithDeadline()/NewWithTimeout()设置,Reset()时重新生效
+	deadline time.Time
+	timeout  time.Duration
+	// values 通过WithValue()添加的值,Reset()时重新添加
+	values []contextValue
+	// stopTimer 释放NewWithDeadline()/NewWithTimeout()创建的计时器
+	stopTimer context.CancelFunc
+	// lifetime 由NewWithMaxLifetime()设置,lifeTimer到期后以ErrTimeout为原因取消
+	lifetime  time.Duration
+	lifeTimer *time.Timer
+	// lifeGen 每次启动lifeTimer时递增,避免已经停止的计时器取消新的内部Context
+	lifeGen uint64
+
+	mu            sync.Mutex
+	failed        []failure
+	firstErr      error
+	panics        []*PanicError
+	cancelOnError bool
+	recover       bool
+	panicHandler  func(recovered interface{}, stack []byte)
+	sem           *semaphore
+	categories    map[string]*semaphore
+	limiter       *rate.Limiter
+	logger        Logger
+	durationMode  DurationMode
+	durations     map[string]time.Duration
+	onStart       func(name string)
+	onRoutineDone func(name string, err error, dur time.Duration)
+	interceptor   Interceptor
+	spawnHook     func()
+	spawnFunc     func(f func())
+	// serial 通过SetSerial()启用串行运行时的队列
+	serial *serialQueue
+	// lastID 最近登记的routine的启动序号,Reset()时清零
+	lastID uint64
+	// created 创建的时间
+	created time.Time
+	// firstLaunch 第一次登记routine时距created的纳秒数加1,为0表示尚未登记,见Age()
+	firstLaunch int64
+	// paused 通过Pause()暂停启动时非nil,Resume()时关闭
+	paused chan struct{}
+	// captureStack 通过SetCaptureLaunchStack()设置,启动routine时记录调用栈
+	captureStack bool
+	// heartbeats 通过Heartbeat()记录的名称到最近一次心跳时间的映射
+	heartbeats sync.Map
+	// named 运行中(包括等待运行槽位)的命名routine按名称的个数,最后一个同名routine结束时才删除其心跳记录
+	named map[string]int
+	// flags 启用的功能,见flagAcquire等
+	flags     uint32
+	running   int32
+	pending   int32
+	cancelled int32
+	// name 通过NewWithName()/SetName()设置的名称,非空时routine运行时设置pprof标签
+	name string
+	// leakWarning 通过SetLeakWarning()设置的泄漏告警
+	leakWarning *leakWarning
+	// onDone 所有routine结束时调用的回调
+	onDone []func()
+	// children 通过Sub()创建的子WaitRoutine
+	children []*WaitRoutine
+	// waiters 通过WaitN()等待的调用者
+	waiters []*waiter
+	// draining 正在调用OnDone()回调的次数,不为0时Done()不会关闭
+	draining int
+	// doneCh Done()返回的channel,在所有routine结束时关闭
+	doneCh chan struct{}
+	// untracked 通过Go()的快速路径运行,没有登记到tasks的routine个数
+	untracked int
+	// stages 按运行方式分别计数的routine,用于WaitSetup()等
+	stages [numKinds]stage
+	// tasks 运行中routine的记录,以id为key
+	tasks map[uint64]*task
+}
+
+// defaultWaitRoutine 包级别函数使用的默认WaitRoutine,为nil时在第一次使用时创建
+var defaultWaitRoutine atomic.Pointer[WaitRoutine]
+
+// Default 返回包级别函数使用的默认WaitRoutine
+//
+// 默认WaitRoutine在第一次使用时以context.Background()为父Context创建,
+// 可以通过SetDefault()替换为自定义的WaitRoutine,比如使用其他父Context.
+// 包级别的Go()/GoRoutine()等运行函数在默认WaitRoutine已被Cancel()且
+// 所有routine都已结束时,会先通过Reset()重新派生内部Context,
+// 避免一次包级别的Cancel()使之后运行的routine永远收到已取消的ctx.
+// Reset()同时会清空之前记录的error和统计信息
+func Default() *WaitRoutine {
+	if c := defaultWaitRoutine.Load(); c != nil {
+		return c
+	}
+	defaultWaitRoutine.CompareAndSwap(nil, New(context.Background()))
+	return defaultWaitRoutine.Load()
 }
 
-// DefaultWaitRoutine 默认WaitRoutine
-var DefaultWaitRoutine = New(context.Background())
+// SetDefault 将包级别函数使用的默认WaitRoutine替换为c,c为nil时效果与ResetDefault()相同
+//
+// 替换不影响原有WaitRoutine中已经运行的routine,包级别的Wait()等只等待新的WaitRoutine
+func SetDefault(c *WaitRoutine) {
+	defaultWaitRoutine.Store(c)
+}
+
+// ResetDefault 丢弃当前的默认WaitRoutine,下一次使用时重新创建
+//
+// 便于测试之间隔离包级别函数的状态
+func ResetDefault() {
+	defaultWaitRoutine.Store(nil)
+}
+
+// defaultRoutine 返回用于运行新routine的默认WaitRoutine
+//
+// 默认WaitRoutine已被取消且没有routine运行时先Reset(),
+// 仍有routine运行时Reset()返回ErrRunning,新的routine与它们一样收到ctx.Done()信号
+func defaultRoutine() *WaitRoutine {
+	c := Default()
+	if c.Cancelled() {
+		_ = c.Reset()
+	}
+	return c
+}
 
-// New 新建一个WaitRoutine
+// New 新建一个WaitRoutine,并按顺序应用opts中的选项
 //
-// 在ctx为nil值时,默认使用context.Background()作为父context
-func New(ctx context.Context) *WaitRoutine {
-	wgc := &WaitRoutine{}
+// 在ctx为nil值时,默认使用context.Background()作为父context.
+// 可用的选项见WithLimit(),WithName()等,不传递选项时为没有任何限制的WaitRoutine
+func New(ctx context.Context, opts ...Option) *WaitRoutine {
+	wgc := &WaitRoutine{created: time.Now()}
+	wgc.setParent(ctx)
+	wgc.derive()
+	for _, opt := range opts {
+		opt(wgc)
+	}
+	return wgc
+}
+
+// setParent 设置父context,ctx为nil时使用context.Background()
+func (c *WaitRoutine) setParent(ctx context.Context) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	wgc.ctx, wgc.cancelFunc = context.WithCancel(ctx)
-	return wgc
+	c.parent = ctx
+}
+
+// derive 从父context派生内部Context,除New()等构造函数外需要持有c.mu
+func (c *WaitRoutine) derive() {
+	ctx := c.parent
+	c.stopTimer = nil
+	if c.timeout > 0 {
+		ctx, c.stopTimer = context.WithTimeout(ctx, c.timeout)
+	} else if !c.deadline.IsZero() {
+		ctx, c.stopTimer = context.WithDeadline(ctx, c.deadline)
+	}
+	c.ctx, c.cancelFunc = context.WithCancelCause(ctx)
+	c.ctx = context.WithValue(c.ctx, groupKey{}, c)
+	c.startLifetime()
+	for _, v := range c.values {
+		c.ctx = context.WithValue(c.ctx, v.key, v.val)
+	}
+}
+
+// Reset 重置WaitRoutine以便重复使用
+//
+// Reset会取消原有的内部Context,并从New()或WithParent()传入的父context重新派生,
+// 同时清除记录的error,panic和Stats统计,并且不再跟踪已经没有routine运行的Sub()子WaitRoutine.
+// 通过NewWithTimeout()创建时重新开始计时,通过NewWithDeadline()创建时deadline保持不变.
+// 仍有routine运行(包括等待运行槽位)时返回ErrRunning且不做任何修改.
+// Reset可以与其他方法并发调用,之后开始运行的routine使用新的内部Context
+func (c *WaitRoutine) Reset() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if atomic.LoadInt32(&c.running) != 0 {
+		return ErrRunning
+	}
+	c.cancelFunc(nil)
+	if c.stopTimer != nil {
+		c.stopTimer()
+	}
+	c.stopLifetime()
+	atomic.StoreInt32(&c.cancelled, 0)
+	c.failed = nil
+	c.firstErr = nil
+	c.panics = nil
+	c.durations = nil
+	atomic.StoreUint64(&c.stats.Launched, 0)
+	atomic.StoreUint64(&c.lastID, 0)
+	atomic.StoreUint64(&c.stats.Completed, 0)
+	atomic.StoreUint64(&c.stats.Panicked, 0)
+	atomic.StoreUint64(&c.stats.Failed, 0)
+	atomic.StoreUint64(&c.stats.Restarted, 0)
+	atomic.StoreUint64(&c.stats.Skipped, 0)
+	c.clearHeartbeats()
+	c.pruneChildren()
+	c.derive()
+	return nil
+}
+
+// WithParent 将父Context替换为ctx并重新派生内部Context,ctx为nil时使用context.Background()
+//
+// 用于创建WaitRoutine时还无法确定父Context的场景,应在运行routine之前调用.
+// 仍有routine运行(包括等待运行槽位)时返回ErrRunning且不做任何修改.
+// 已经调用过Cancel()/CancelCause()时,新的内部Context同样以原有的原因被取消
+func (c *WaitRoutine) WithParent(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if atomic.LoadInt32(&c.running) != 0 {
+		return ErrRunning
+	}
+	cause := context.Cause(c.ctx)
+	c.cancelFunc(nil)
+	if c.stopTimer != nil {
+		c.stopTimer()
+	}
+	c.stopLifetime()
+	c.setParent(ctx)
+	c.derive()
+	if atomic.LoadInt32(&c.cancelled) != 0 {
+		c.cancelFunc(cause)
+	}
+	return nil
+}
+
+// run 在routine所在的go routine中以t.ctx运行fn
+//
+// 设置了名称时通过pprof.Do()添加标签,OnStart()注册的回调在标签设置之后,fn运行之前调用
+func (c *WaitRoutine) run(t *task, fn func(ctx context.Context)) {
+	if t.group != "" {
+		pprof.Do(t.ctx, c.labels(t), func(ctx context.Context) {
+			c.started(t)
+			c.intercept(t, ctx, fn)
+		})
+		return
+	}
+	c.started(t)
+	c.intercept(t, t.ctx, fn)
+}
+
+func (c *WaitRoutine) goFn(t *task, sem *semaphore, fn func()) {
+	defer c.done(t)
+	defer c.release(sem)
+	c.begin(t)
+	if c.recoverable() {
+		defer c.recoverPanic(t)
+	}
+	if t.group == "" && t.interceptor == nil {
+		// 不需要pprof标签和Interceptor时直接调用fn,省去包装闭包的内存分配
+		c.started(t)
+		fn()
+		return
+	}
+	c.run(t, func(context.Context) { fn() })
+}
+
+// goBare 没有启用任何功能时Go()的快速路径
+//
+// 不创建task也不登记到tasks,Dump()只输出这些routine的个数,
+// 每个fn只有启动go routine时的一次内存分配
+func (c *WaitRoutine) goBare(fns []func()) {
+	n := len(fns)
+	if atomic.LoadInt64(&c.firstLaunch) == 0 {
+		c.launched(time.Now())
+	}
+	c.mu.Lock()
+	atomic.AddInt32(&c.running, int32(n))
+	atomic.AddUint64(&c.stats.Launched, uint64(n))
+	c.stages[kindSetup].running += n
+	c.untracked += n
+	c.mu.Unlock()
+	c.wg.Add(n)
+	for _, fn := range fns {
+		go c.goBareFn(fn)
+	}
 }
 
-func (c *WaitRoutine) goFn(fn func()) {
+func (c *WaitRoutine) goBareFn(fn func()) {
+	defer c.bareDone()
 	fn()
+}
+
+// bareDone 通过goBare()运行的routine结束
+func (c *WaitRoutine) bareDone() {
+	completed := atomic.AddUint64(&c.stats.Completed, 1)
+	c.mu.Lock()
+	c.untracked--
+	c.finishStage(kindSetup)
+	last := atomic.AddInt32(&c.running, -1) == 0
+	if last {
+		c.draining++
+	}
+	c.notifyWaiters(nil, completed, last)
+	c.mu.Unlock()
+	if last {
+		c.drained()
+	}
 	c.wg.Done()
 }
 
 // Go 运行参数传递的routines,类型为func()
 //
 // 接收不定个数func(),所有都会运行
-// 该接口一般用于不需要context的go routine调用
+// 该接口一般用于不需要context的go routine调用,可以通过WaitSetup()只等待通过Go()运行的routine
 func (c *WaitRoutine) Go(fns ...func()) *WaitRoutine {
-	for _, fn := range fns {
-		c.wg.Add(1)
-		go c.goFn(fn)
+	if len(fns) > 0 && c.bare() {
+		c.goBare(fns)
+		return c
+	}
+	tasks, queued := c.addN(len(fns), kindSetup)
+	for i, fn := range fns {
+		t, sem, fn := &tasks[i], c.acquireBatch(i, queued), fn
+		c.spawn(func() { c.goFn(t, sem, fn) })
 	}
 	return c
 }
 
-func (c *WaitRoutine) goRoutine(routine Routine) {
-	routine(c.ctx)
-	c.wg.Done()
+func (c *WaitRoutine) goRoutine(t *task, sem *semaphore, routine Routine) {
+	defer c.done(t)
+	defer c.release(sem)
+	c.begin(t)
+	if c.recoverable() {
+		defer c.recoverPanic(t)
+	}
+	c.run(t, routine)
 }
 
 // GoRoutine 运行参数传递的routines,类型Routine
 //
 // 接收不定个数Routine,所有都会运行
-// 该接口会传递context.Context,go routine可以根据context决定是否结束,或者从中获取相关参数
+// 该接口会传递context.Context,go routine可以根据context决定是否结束,或者从中获取相关参数.
+// 可以通过WaitRoutines()只等待通过GoRoutine()运行的routine
 func (c *WaitRoutine) GoRoutine(routines ...Routine) *WaitRoutine {
-	for _, routine := range routines {
-		c.wg.Add(1)
-		go c.goRoutine(routine)
+	tasks, queued := c.addN(len(routines), kindRoutine)
+	for i, routine := range routines {
+		t, sem, routine := &tasks[i], c.acquireBatch(i, queued), routine
+		c.spawn(func() { c.goRoutine(t, sem, routine) })
+	}
+	return c
+}
+
+// GoRoutineIndexed 运行n个fn,每个fn接收其序号i,i为0到n-1
+//
+// 取消和等待的行为与GoRoutine()相同,适用于按worker序号分片处理的场景
+func (c *WaitRoutine) GoRoutineIndexed(n int, fn func(ctx context.Context, i int)) *WaitRoutine {
+	for i := 0; i < n; i++ {
+		i := i
+		c.GoRoutine(func(ctx context.Context) {
+			fn(ctx, i)
+		})
 	}
 	return c
 }
 
 // Cancel 取消所有Routine运行,如果已经运行,则ctx参数会接收到ctx.Done()信号
+//
+// Cancel可以重复调用,也可以在Wait()返回之后或者在多个go routine中并发调用(比如信号处理),
+// 只有第一次调用生效,之后的调用没有任何影响
 func (c *WaitRoutine) Cancel() {
-	c.cancelFunc()
+	c.CancelCause(nil)
+}
+
+// CancelCause 以err为原因取消所有Routine运行,routine可以通过context.Cause(ctx)获取err
+//
+// err为nil时与Cancel()相同,原因为context.Canceled.
+// 与Cancel()相同,只有第一次调用生效,之后调用不会改变取消原因
+func (c *WaitRoutine) CancelCause(err error) {
+	c.mu.Lock()
+	c.cancelLocked(err)
+	c.mu.Unlock()
+}
+
+// cancelLocked 以err为原因取消内部Context并标记为Cancelled(),需要持有c.mu
+//
+// 已经调用过时不做任何修改并返回false
+func (c *WaitRoutine) cancelLocked(err error) bool {
+	if atomic.LoadInt32(&c.cancelled) != 0 {
+		return false
+	}
+	atomic.StoreInt32(&c.cancelled, 1)
+	c.cancelFunc(err)
+	if c.stopTimer != nil {
+		c.stopTimer()
+	}
+	return true
+}
+
+// stop 取消内部Context并释放计时器,不标记为Cancelled(),返回是否有计时器
+func (c *WaitRoutine) stop(err error) bool {
+	c.mu.Lock()
+	cancel, stopTimer := c.cancelFunc, c.stopTimer
+	c.mu.Unlock()
+	cancel(err)
+	if stopTimer == nil {
+		return false
+	}
+	stopTimer()
+	return true
+}
+
+// Cancelled 返回是否调用过Cancel()/CancelCause()取消所有Routine运行
+//
+// 只反映WaitRoutine自身的取消(包括cancelOnError模式下routine返回error触发的取消),
+// 父Context被取消或者超时导致内部Context结束时返回false.
+// 因此Context().Err()不为nil且Cancelled()返回false时,说明是父Context或者超时导致的结束
+func (c *WaitRoutine) Cancelled() bool {
+	return atomic.LoadInt32(&c.cancelled) != 0
 }
 
 // Wait 等待所有Routine运行结束或者被取消
+//
+// 在运行中的routine里调用Go()/GoChild()等登记的新routine总是会被等待,
+// 因为登记发生在调用者结束之前,运行个数不会降为0.
+// 从其他go routine与Wait()并发调用Go()时没有顺序保证,Wait()可能在其登记之前返回,
+// 需要等待的routine应在调用Wait()之前登记.
+// 通过SetLeakWarning()设置了泄漏告警时,等待期间没有进展会调用告警回调.
+// 通过NewWithDeadline()/NewWithTimeout()创建时,Wait()返回前会释放计时器并取消内部Context.
+// 通过NewWithMaxLifetime()创建时,Wait()返回前会停止计时器,不会再因为到期而取消
 func (c *WaitRoutine) Wait() {
-	c.wg.Wait()
+	stop := c.watchLeak()
+	<-c.Done()
+	stop()
+	c.mu.Lock()
+	hasTimer := c.stopTimer != nil
+	c.stopLifetime()
+	c.mu.Unlock()
+	if hasTimer {
+		c.stop(nil)
+	}
+}
+
+// WaitAndReset 等待所有Routine运行结束,然后Reset(),返回这一批routine的Err()
+//
+// 适用于分批处理的场景:运行一批routine,等待结束,再运行下一批.
+// 即使上一批中调用过Cancel(),下一批routine也会得到新的未取消的Context.
+// 重新派生的Context仍然来自父Context,父Context已经被取消时新的Context同样是取消状态.
+// 可以与其他方法并发调用,Wait()返回之后Reset()之前又有routine开始运行时,
+// 与Reset()相同返回ErrRunning且不做任何修改
+func (c *WaitRoutine) WaitAndReset() error {
+	c.Wait()
+	err := c.Err()
+	if rerr := c.Reset(); rerr != nil {
+		return rerr
+	}
+	return err
 }
 
 // WaitGroup 返回内部WaitGroup结构
+//
+// Deprecated: WaitRoutine内部不再通过sync.WaitGroup等待,返回的WaitGroup包含所有方式运行的routine的计数,
+// 对其调用Add()/Done()不会影响Wait()等方法.请使用Wait()/WaitTimeout()/WaitContext()/Done(),
+// 或者分别等待的WaitSetup()/WaitRoutines()
 func (c *WaitRoutine) WaitGroup() *sync.WaitGroup {
 	return &c.wg
 }
 
 // Context 返回内部Context结构
 func (c *WaitRoutine) Context() context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.ctx
 }
 
-// Go 通过DefaultWaitRoutine运行参数传递的routines,类型为func()
+// ParentContext 返回New()等构造函数或WithParent()传入的父Context
+//
+// 内部Context由其派生,Reset()时也从其重新派生.
+// 可用于创建共享同一父Context取消链的其他WaitRoutine
+func (c *WaitRoutine) ParentContext() context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.parent
+}
+
+// Cause 返回内部Context被取消的原因,未被取消时返回nil
+//
+// 等同于context.Cause(c.Context())
+func (c *WaitRoutine) Cause() error {
+	return context.Cause(c.Context())
+}
+
+// Go 通过默认WaitRoutine运行参数传递的routines,类型为func()
 //
 // 接收不定个数func(),所有都会运行
 func Go(fns ...func()) *WaitRoutine {
-	return DefaultWaitRoutine.Go(fns...)
+	return defaultRoutine().Go(fns...)
 }
 
-
-// Go 通过DefaultWaitRoutine运行参数传递的routines,类型为Routine
+// Go 通过默认WaitRoutine运行参数传递的routines,类型为Routine
 //
 // 接收不定个数Routine,所有都会运行
 func GoRoutine(routines ...Routine) *WaitRoutine {
-	return DefaultWaitRoutine.GoRoutine(routines...)
+	return defaultRoutine().GoRoutine(routines...)
+}
+
+// GoRoutineIndexed 通过默认WaitRoutine运行n个fn,每个fn接收其序号i
+func GoRoutineIndexed(n int, fn func(ctx context.Context, i int)) *WaitRoutine {
+	return defaultRoutine().GoRoutineIndexed(n, fn)
 }
 
-// Cancel 通过DefaultWaitRoutine取消所有Routine运行,
+// Cancel 通过默认WaitRoutine取消所有Routine运行,
 // 如果已经运行,则ctx参数会接收到ctx.Done()信号
+//
+// 所有routine结束后,之后包级别运行的routine会使用重新派生的内部Context
 func Cancel() {
-	DefaultWaitRoutine.cancelFunc()
+	Default().Cancel()
+}
+
+// CancelCause 通过默认WaitRoutine以err为原因取消所有Routine运行
+func CancelCause(err error) {
+	Default().CancelCause(err)
 }
 
-// Wait 通过DefaultWaitRoutine等待所有Routine运行结束或者被取消
+// Wait 通过默认WaitRoutine等待所有Routine运行结束或者被取消
 func Wait() {
-	DefaultWaitRoutine.Wait()
+	Default().Wait()
 }
 
-// WaitGroup 通过DefaultWaitRoutine返回内部WaitGroup结构
+// WaitGroup 通过默认WaitRoutine返回内部WaitGroup结构
+//
+// Deprecated: 请使用Wait()/WaitTimeout()/WaitContext()/Done()
 func WaitGroup() *sync.WaitGroup {
-	return DefaultWaitRoutine.WaitGroup()
+	return Default().WaitGroup()
 }
 
-// Context 通过DefaultWaitRoutine返回内部Context结构
+// Context 通过默认WaitRoutine返回内部Context结构
 func Context() context.Context {
-	return DefaultWaitRoutine.Context()
+	return Default().Context()
+}
+
+// Cancelled 通过默认WaitRoutine返回是否调用过Cancel()/CancelCause()
+func Cancelled() bool {
+	return Default().Cancelled()
+}
+
+// ParentContext 通过默认WaitRoutine返回父Context
+func ParentContext() context.Context {
+	return Default().ParentContext()
+}
+
+// Cause 通过默认WaitRoutine返回内部Context被取消的原因
+func Cause() error {
+	return Default().Cause()
 }
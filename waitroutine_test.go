@@ -16,6 +16,8 @@ package waitroutine
 
 import (
 	"context"
+	"errors"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -35,9 +37,19 @@ func routine(ctx context.Context) {
 	}
 }
 
+func finished(wg *WaitRoutine, d time.Duration) bool {
+	select {
+	case <-wg.Done():
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
 func TestWaitRoutine_Wait(t *testing.T) {
 	waitSecond := time.Second * 5
-	ctx, _ := context.WithTimeout(context.Background(), waitSecond)
+	ctx, cancel := context.WithTimeout(context.Background(), waitSecond)
+	defer cancel()
 
 	wg := New(ctx)
 	wg.GoRoutine(routine)
@@ -74,3 +86,295 @@ func TestWaitRoutine_Cancel(t *testing.T) {
 	wg.Wait()
 	t.Logf("%s now exit", timestamp())
 }
+
+func TestWaitRoutine_CancelCause(t *testing.T) {
+	errShutdown := errors.New("shutdown")
+
+	wg := New(context.Background())
+	wg.GoRoutineE(func(ctx context.Context) error {
+		<-ctx.Done()
+		return context.Cause(ctx)
+	})
+	wg.CancelCause(errShutdown)
+	wg.Wait()
+
+	if err := wg.Err(); err != errShutdown {
+		t.Fatalf("expect routine observed cause %v, got %v", errShutdown, err)
+	}
+	if err := wg.Cause(); err != errShutdown {
+		t.Fatalf("expect cause %v, got %v", errShutdown, err)
+	}
+
+	wg = New(context.Background())
+	wg.Cancel()
+	if err := wg.Cause(); err != context.Canceled {
+		t.Fatalf("expect cause %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestWaitRoutine_Reset(t *testing.T) {
+	release := make(chan struct{})
+	wg := New(context.Background())
+	wg.GoE(func() error {
+		<-release
+		return errors.New("reset")
+	})
+	if err := wg.Reset(); err != ErrRunning {
+		t.Fatalf("expect %v, got %v", ErrRunning, err)
+	}
+	close(release)
+	wg.Cancel()
+	wg.Wait()
+
+	if err := wg.Reset(); err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+	if err := wg.Context().Err(); err != nil {
+		t.Fatalf("expect fresh context, got %v", err)
+	}
+	if err := wg.Err(); err != nil {
+		t.Fatalf("expect errors cleared, got %v", err)
+	}
+	if stats := wg.Stats(); stats != (Stats{}) {
+		t.Fatalf("expect stats cleared, got %+v", stats)
+	}
+}
+
+func TestWaitRoutine_ParentContext(t *testing.T) {
+	type key struct{}
+	parent := context.WithValue(context.Background(), key{}, "parent")
+
+	wg := New(parent)
+	if wg.ParentContext() != parent {
+		t.Fatal("expect ParentContext returns the context passed to New")
+	}
+	wg.Cancel()
+	if err := wg.ParentContext().Err(); err != nil {
+		t.Fatalf("expect parent not cancelled by Cancel, got %v", err)
+	}
+
+	if New(nil).ParentContext() != context.Background() {
+		t.Fatal("expect nil parent defaults to context.Background")
+	}
+}
+
+func TestWaitRoutine_WithParent(t *testing.T) {
+	type key struct{}
+	parent, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "late"))
+	defer cancel()
+
+	wg := New(nil)
+	if err := wg.WithParent(parent); err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+	if wg.ParentContext() != parent {
+		t.Fatal("expect ParentContext returns the context passed to WithParent")
+	}
+	if v := wg.Context().Value(key{}); v != "late" {
+		t.Fatalf("expect value from new parent, got %v", v)
+	}
+
+	release := make(chan struct{})
+	wg.Go(func() { <-release })
+	if err := wg.WithParent(context.Background()); err != ErrRunning {
+		t.Fatalf("expect %v, got %v", ErrRunning, err)
+	}
+	close(release)
+	wg.Wait()
+
+	wg.Cancel()
+	if wg.Context().Err() == nil {
+		t.Fatal("expect Cancel cancels the re-derived context")
+	}
+	if err := wg.WithParent(context.Background()); err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+	if wg.Context().Err() == nil {
+		t.Fatal("expect WithParent keeps the group cancelled")
+	}
+
+	wg = New(context.Background())
+	if err := wg.WithParent(parent); err != nil {
+		t.Fatalf("expect nil error, got %v", err)
+	}
+	cancel()
+	if wg.Context().Err() == nil {
+		t.Fatal("expect new parent cancellation propagates")
+	}
+}
+
+func TestWaitRoutine_Cancelled(t *testing.T) {
+	wg := New(context.Background())
+	if wg.Cancelled() {
+		t.Fatal("expect new group not cancelled")
+	}
+	wg.Cancel()
+	if !wg.Cancelled() {
+		t.Fatal("expect group cancelled after Cancel")
+	}
+
+	parent, cancel := context.WithCancel(context.Background())
+	wg = New(parent)
+	cancel()
+	if wg.Context().Err() == nil || wg.Cancelled() {
+		t.Fatal("expect parent cancellation not reported as Cancelled")
+	}
+
+	wg = NewWithTimeout(context.Background(), time.Hour)
+	wg.Wait()
+	if wg.Cancelled() {
+		t.Fatal("expect timer release in Wait not reported as Cancelled")
+	}
+}
+
+func TestWaitRoutine_GoRoutineIndexed(t *testing.T) {
+	const n = 8
+	var seen [n]int32
+
+	wg := New(context.Background())
+	wg.GoRoutineIndexed(n, func(ctx context.Context, i int) {
+		seen[i]++
+	})
+	wg.Wait()
+
+	for i, v := range seen {
+		if v != 1 {
+			t.Fatalf("expect worker %d ran once, got %d", i, v)
+		}
+	}
+}
+
+func TestWaitRoutine_WaitAndReset(t *testing.T) {
+	errBatch := errors.New("batch")
+
+	wg := New(context.Background())
+	wg.GoRoutineE(func(ctx context.Context) error {
+		<-ctx.Done()
+		return errBatch
+	})
+	wg.Cancel()
+	if err := wg.WaitAndReset(); err != errBatch {
+		t.Fatalf("expect batch error %v, got %v", errBatch, err)
+	}
+
+	wg.GoRoutineE(func(ctx context.Context) error {
+		return ctx.Err()
+	})
+	if err := wg.WaitAndReset(); err != nil {
+		t.Fatalf("expect next batch got fresh context, got %v", err)
+	}
+}
+
+func TestWaitRoutine_CancelIdempotent(t *testing.T) {
+	errFirst := errors.New("first")
+
+	wg := New(context.Background())
+	wg.GoRoutine(routine, routine)
+
+	start := make(chan struct{})
+	cancellers := New(context.Background())
+	for i := 0; i < 100; i++ {
+		i := i
+		cancellers.Go(func() {
+			<-start
+			if i == 0 {
+				wg.CancelCause(errFirst)
+			} else {
+				wg.Cancel()
+			}
+		})
+	}
+	close(start)
+	cancellers.Wait()
+	wg.Wait()
+
+	wg.Cancel()
+	wg.CancelCause(errors.New("after wait"))
+	if !wg.Cancelled() {
+		t.Fatal("expect group cancelled")
+	}
+	if err := wg.Cause(); err != errFirst && err != context.Canceled {
+		t.Fatalf("expect cause from the first concurrent cancel, got %v", err)
+	}
+}
+
+func TestDefaultWaitRoutineAfterCancel(t *testing.T) {
+	Cancel()
+	Wait()
+	if !Cancelled() {
+		t.Fatalf("Cancelled() = false after Cancel()")
+	}
+	errCh := make(chan error, 1)
+	GoRoutine(func(ctx context.Context) {
+		errCh <- ctx.Err()
+	})
+	Wait()
+	if err := <-errCh; err != nil {
+		t.Fatalf("routine ctx.Err() = %v after package-level Cancel, want nil", err)
+	}
+	if Cancelled() {
+		t.Fatalf("Cancelled() = true after default WaitRoutine re-armed")
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	defer ResetDefault()
+
+	type key struct{}
+	wg := New(context.WithValue(context.Background(), key{}, "custom"))
+	SetDefault(wg)
+	if Default() != wg {
+		t.Fatal("expect Default returns the group passed to SetDefault")
+	}
+	got := make(chan interface{}, 1)
+	GoRoutine(func(ctx context.Context) { got <- ctx.Value(key{}) })
+	Wait()
+	if v := <-got; v != "custom" {
+		t.Fatalf("expect routine ctx derived from custom parent, got %v", v)
+	}
+
+	ResetDefault()
+	if c := Default(); c == wg || c.ParentContext() != context.Background() {
+		t.Fatal("expect ResetDefault lazily creates a fresh group")
+	}
+	if Default() != Default() {
+		t.Fatal("expect Default returns the same group until replaced")
+	}
+}
+
+func TestWaitRoutine_GoBareAllocs(t *testing.T) {
+	wg := New(context.Background())
+	fn := func() {}
+	allocs := testing.AllocsPerRun(1000, func() {
+		wg.Go(fn)
+		for wg.Running() != 0 {
+			runtime.Gosched()
+		}
+	})
+	wg.Wait()
+	if allocs > 1 {
+		t.Fatalf("expect at most 1 alloc per bare Go, got %v", allocs)
+	}
+}
+
+func BenchmarkGoBare(b *testing.B) {
+	wg := New(context.Background())
+	fn := func() {}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wg.Go(fn)
+	}
+	wg.Wait()
+}
+
+func BenchmarkGoWithFeatures(b *testing.B) {
+	wg := NewWithRecover(context.Background()).SetLimit(1 << 20).RecordDurations(DurationTotal)
+	fn := func() {}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wg.Go(fn)
+	}
+	wg.Wait()
+}
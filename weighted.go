@@ -0,0 +1,64 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"math"
+)
+
+// GoWeighted 以weight权重运行fn,fn运行期间占用SetLimit()设置的weight个槽位
+//
+// 用于耗费资源不同的任务共用同一并发限制,比如在限制为10的WaitRoutine中,
+// 权重为4的任务与最多6个权重为1的任务同时运行.
+// weight<1时按1计算;weight超过限制总数时占用全部槽位,即等待其他routine全部结束后独占运行,
+// 运行期间其他routine需要等待,不会返回错误.
+// 等待槽位时按顺序获取,权重大的routine在队首等待时后面的routine同样需要等待.
+// 没有设置限制时与Go()相同.fn返回或者panic时都会释放占用的槽位
+func (c *WaitRoutine) GoWeighted(weight int64, fn func()) *WaitRoutine {
+	t := c.add("")
+	sem, n := c.acquireWeighted(weight)
+	c.spawn(func() {
+		c.goFn(t, nil, func() {
+			if sem != nil {
+				defer sem.release(n)
+			}
+			fn()
+		})
+	})
+	return c
+}
+
+// acquireWeighted 获取权重为weight的运行槽位,返回信号量和实际占用的槽位个数,未设置限制时返回nil
+func (c *WaitRoutine) acquireWeighted(weight int64) (*semaphore, int) {
+	if !c.hasFlag(flagAcquire) {
+		return nil, 0
+	}
+	switch {
+	case weight < 1:
+		weight = 1
+	case weight > math.MaxInt32:
+		weight = math.MaxInt32
+	}
+	c.waitRate()
+	c.mu.Lock()
+	sem := c.sem
+	c.mu.Unlock()
+	return sem, c.wait(sem, int(weight), 0)
+}
+
+// GoWeighted 通过默认WaitRoutine以weight权重运行fn
+func GoWeighted(weight int64, fn func()) *WaitRoutine {
+	return defaultRoutine().GoWeighted(weight, fn)
+}
@@ -0,0 +1,90 @@
+// Copyright © 2020 sqos <sqos4os@yandex.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package waitroutine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitRoutine_GoWeighted(t *testing.T) {
+	const limit = 10
+	var used, maxUsed int32
+	track := func(weight int32) func() {
+		return func() {
+			n := atomic.AddInt32(&used, weight)
+			for {
+				max := atomic.LoadInt32(&maxUsed)
+				if n <= max || atomic.CompareAndSwapInt32(&maxUsed, max, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&used, -weight)
+		}
+	}
+
+	wg := NewWithLimit(context.Background(), limit)
+	for i := 0; i < 20; i++ {
+		wg.GoWeighted(4, track(4))
+		wg.GoWeighted(1, track(1))
+	}
+	wg.Wait()
+	if maxUsed > limit {
+		t.Fatalf("expect at most %d units in use, got %d", limit, maxUsed)
+	}
+	if maxUsed < 5 {
+		t.Fatalf("expect heavy and light routines run together, got %d units at most", maxUsed)
+	}
+}
+
+func TestWaitRoutine_GoWeightedExclusive(t *testing.T) {
+	release := make(chan struct{})
+	wg := NewWithLimit(context.Background(), 3)
+	wg.Go(func() { <-release })
+
+	var exclusive int32
+	go wg.GoWeighted(100, func() { atomic.StoreInt32(&exclusive, 1) })
+	deadline := time.Now().Add(5 * time.Second)
+	for wg.Pending() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect oversized routine waits for the whole pool, got %d pending", wg.Pending())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if wg.TryGo(func() {}) {
+		t.Fatal("expect TryGo rejected while an oversized routine waits")
+	}
+	close(release)
+	wg.Wait()
+	if atomic.LoadInt32(&exclusive) != 1 {
+		t.Fatal("expect oversized routine runs exclusively")
+	}
+}
+
+func TestWaitRoutine_GoWeightedPanic(t *testing.T) {
+	wg := NewWithRecover(context.Background()).SetLimit(2)
+	wg.GoWeighted(2, func() { panic("heavy") })
+	wg.Wait()
+	if !wg.TryGo(func() {}) {
+		t.Fatal("expect weight released after panic")
+	}
+	wg.Wait()
+	if wg.Err() == nil {
+		t.Fatal("expect panic recorded")
+	}
+}